@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+// errorFormat is the value of the global --error-format flag.
+var errorFormat = "text"
+
+var errorFormatFlag = cli.StringFlag{
+	Name: "error-format",
+	Usage: `The <format> used to print errors, "text" or "json". The JSON output is an
+object with the "error" message, the exit "code" and the "type" of the error:
+"flag", "argument", "file", "api" or "error".`,
+	Value:  "text",
+	EnvVar: "STEP_ERROR_FORMAT",
+}
+
+// setErrorFormat validates and stores the --error-format flag.
+func setErrorFormat(ctx *cli.Context) error {
+	switch format := ctx.GlobalString("error-format"); format {
+	case "text", "json":
+		errorFormat = format
+		return nil
+	default:
+		return errs.InvalidFlagValue(ctx, "error-format", format, "text, json")
+	}
+}
+
+// handleExitErr is the cli.ExitErrHandlerFunc used by the app. With the JSON
+// error format, errors with an exit code are returned to the caller instead of
+// being printed by the cli package.
+func handleExitErr(ctx *cli.Context, err error) {
+	if errorFormat != "json" {
+		cli.HandleExitCoder(err)
+	}
+}
+
+// The regular expressions used to classify the errors that are not created
+// with the errs package, like the ones returned by other modules using the
+// helpers in go.step.sm/cli-utils/errs.
+var (
+	fileErrorRegexp     = regexp.MustCompile(`^(\w+ \S+ (\S+ )?failed: |unexpected error on )`)
+	argumentErrorRegexp = regexp.MustCompile(`positional arguments? `)
+	flagErrorRegexp     = regexp.MustCompile(`'--[^']+'|one of flag `)
+)
+
+// jsonError is the representation of an error with the JSON error format.
+type jsonError struct {
+	Error string `json:"error"`
+	Code  int    `json:"code"`
+	Type  string `json:"type"`
+}
+
+// newJSONError returns the JSON representation of the given error.
+func newJSONError(err error) jsonError {
+	code := 1
+	var exitErr cli.ExitCoder
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	}
+
+	msg := err.Error()
+	if fe, ok := err.(errs.FriendlyError); ok {
+		msg = fe.Message()
+	}
+
+	var (
+		flagErr     *errs.FlagError
+		argErr      *errs.ArgumentError
+		fileErr     *errs.FileAccessError
+		pathErr     *os.PathError
+		linkErr     *os.LinkError
+		syscallErr  *os.SyscallError
+		adminErr    *ca.AdminClientError
+		typ         = "error"
+		causeString = pkgerrors.Cause(err).Error()
+	)
+	switch {
+	case errors.As(err, &fileErr), errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &syscallErr):
+		typ = "file"
+	case errors.As(err, &argErr):
+		typ = "argument"
+	case errors.As(err, &flagErr):
+		typ = "flag"
+	case errors.As(err, &adminErr):
+		typ = "api"
+	// Fallback for the errors without a type.
+	case fileErrorRegexp.MatchString(causeString), fileErrorRegexp.MatchString(msg):
+		typ = "file"
+	case argumentErrorRegexp.MatchString(causeString):
+		typ = "argument"
+	case flagErrorRegexp.MatchString(causeString):
+		typ = "flag"
+	}
+
+	return jsonError{Error: msg, Code: code, Type: typ}
+}
+
+// printJSONError writes the JSON representation of the given error and
+// returns the exit code.
+func printJSONError(w io.Writer, err error) int {
+	e := newJSONError(err)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(e) != nil {
+		fmt.Fprintln(w, err)
+	}
+	return e.Code
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	clierrs "go.step.sm/cli-utils/errs"
+)
+
+func TestNewJSONError(t *testing.T) {
+	ctx := cli.NewContext(cli.NewApp(), flag.NewFlagSet("test", 0), nil)
+	ctx.Command = cli.Command{Name: "test"}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantType string
+	}{
+		{"error", errors.New("something failed"), 1, "error"},
+		{"wrapped path error", errors.Wrap(&os.PathError{Op: "open", Path: "ca.json", Err: os.ErrNotExist}, "error reading"), 1, "file"},
+		{"api", errors.Wrap(&ca.AdminClientError{Type: "notFound", Message: "not found"}, "error"), 1, "api"},
+		{"exit code", errs.NewExitError(errors.New("not found"), 3), 3, "error"},
+		{"wrapped flag error", errors.Wrap(errs.RequiredFlag(ctx, "foo"), "error"), 1, "flag"},
+		{"flag error with an argument message", errs.InvalidFlagValueMsg(ctx, "foo", "bar", "too many positional arguments"), 1, "flag"},
+		// errs constructors
+		{"InsecureCommand", errs.InsecureCommand(ctx), 1, "flag"},
+		{"EqualArguments", errs.EqualArguments(ctx, "foo", "bar"), 1, "argument"},
+		{"MissingArguments", errs.MissingArguments(ctx, "foo"), 1, "argument"},
+		{"NumberOfArguments", errs.NumberOfArguments(ctx, 1), 1, "argument"},
+		{"MinMaxNumberOfArguments", errs.MinMaxNumberOfArguments(ctx, 1, 2), 1, "argument"},
+		{"TooFewArguments", errs.TooFewArguments(ctx), 1, "argument"},
+		{"TooManyArguments", errs.TooManyArguments(ctx), 1, "argument"},
+		{"InsecureArgument", errs.InsecureArgument(ctx, "foo"), 1, "argument"},
+		{"FlagValueInsecure", errs.FlagValueInsecure(ctx, "foo", "bar"), 1, "flag"},
+		{"InvalidFlagValue", errs.InvalidFlagValue(ctx, "foo", "bar", ""), 1, "flag"},
+		{"InvalidFlagValueMsg", errs.InvalidFlagValueMsg(ctx, "foo", "bar", "msg"), 1, "flag"},
+		{"IncompatibleFlag", errs.IncompatibleFlag(ctx, "foo", "bar"), 1, "flag"},
+		{"IncompatibleFlagWithFlag", errs.IncompatibleFlagWithFlag(ctx, "foo", "bar"), 1, "flag"},
+		{"IncompatibleFlagValue", errs.IncompatibleFlagValue(ctx, "foo", "bar", "baz"), 1, "flag"},
+		{"IncompatibleFlagValues", errs.IncompatibleFlagValues(ctx, "foo", "1", "bar", "2"), 1, "flag"},
+		{"IncompatibleFlagValueWithFlagValue", errs.IncompatibleFlagValueWithFlagValue(ctx, "foo", "1", "bar", "2", ""), 1, "flag"},
+		{"RequiredFlag", errs.RequiredFlag(ctx, "foo"), 1, "flag"},
+		{"RequiredWithFlag", errs.RequiredWithFlag(ctx, "foo", "bar"), 1, "flag"},
+		{"RequiredWithFlagValue", errs.RequiredWithFlagValue(ctx, "foo", "1", "bar"), 1, "flag"},
+		{"RequiredWithProvisionerTypeFlag", errs.RequiredWithProvisionerTypeFlag(ctx, "JWK", "foo"), 1, "flag"},
+		{"RequiredInsecureFlag", errs.RequiredInsecureFlag(ctx, "foo"), 1, "flag"},
+		{"RequiredSubtleFlag", errs.RequiredSubtleFlag(ctx, "foo"), 1, "flag"},
+		{"RequiredUnlessInsecureFlag", errs.RequiredUnlessInsecureFlag(ctx, "foo"), 1, "flag"},
+		{"RequiredUnlessFlag", errs.RequiredUnlessFlag(ctx, "foo", "bar"), 1, "flag"},
+		{"RequiredUnlessSubtleFlag", errs.RequiredUnlessSubtleFlag(ctx, "foo"), 1, "flag"},
+		{"RequiredOrFlag", errs.RequiredOrFlag(ctx, "foo", "bar"), 1, "flag"},
+		{"RequiredWithOrFlag", errs.RequiredWithOrFlag(ctx, "foo", "bar", "baz"), 1, "flag"},
+		{"MinSizeFlag", errs.MinSizeFlag(ctx, "foo", "2048"), 1, "flag"},
+		{"MinSizeInsecureFlag", errs.MinSizeInsecureFlag(ctx, "foo", "2048"), 1, "flag"},
+		{"MutuallyExclusiveFlags", errs.MutuallyExclusiveFlags(ctx, "foo", "bar"), 1, "flag"},
+		{"UnsupportedFlag", errs.UnsupportedFlag(ctx, "foo"), 1, "flag"},
+		{"FileError", errs.FileError(&os.PathError{Op: "open", Path: "ca.json", Err: os.ErrNotExist}, "ca.json"), 1, "file"},
+		{"FileError unexpected", errs.FileError(errors.New("something failed"), "ca.json"), 1, "file"},
+		// Untyped errors, classified by their message.
+		{"untyped flag", clierrs.MutuallyExclusiveFlags(ctx, "foo", "bar"), 1, "flag"},
+		{"untyped argument", clierrs.TooFewArguments(ctx), 1, "argument"},
+		{"untyped file", clierrs.FileError(&os.PathError{Op: "open", Path: "ca.json", Err: os.ErrNotExist}, "ca.json"), 1, "file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newJSONError(tt.err)
+			if got.Code != tt.wantCode || got.Type != tt.wantType {
+				t.Errorf("newJSONError() = %+v, want code %d and type %s", got, tt.wantCode, tt.wantType)
+			}
+			if got.Error == "" {
+				t.Error("newJSONError() error message is empty")
+			}
+		})
+	}
+}
@@ -14,9 +14,9 @@ import (
 	"github.com/smallstep/certificates/ca"
 	"github.com/smallstep/cli/command/version"
 	"github.com/smallstep/cli/usage"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
 	"go.step.sm/cli-utils/command"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/step"
 
 	// Enabled commands
@@ -85,6 +85,9 @@ func main() {
 		Name:  "config",
 		Usage: "path to the config file to use for CLI flags",
 	})
+	app.Flags = append(app.Flags, errorFormatFlag)
+	app.Before = setErrorFormat
+	app.ExitErrHandler = handleExitErr
 
 	// All non-successful output should be written to stderr
 	app.Writer = os.Stdout
@@ -100,6 +103,11 @@ func main() {
 	}
 
 	if err := app.Run(os.Args); err != nil {
+		if errorFormat == "json" {
+			code := printJSONError(os.Stderr, err)
+			// nolint:gocritic
+			os.Exit(code)
+		}
 		if fe, ok := err.(errs.FriendlyError); ok {
 			if os.Getenv("STEPDEBUG") == "1" {
 				fmt.Fprintf(os.Stderr, "%+v\n\n%s", err, fe.Message())
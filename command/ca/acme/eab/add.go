@@ -8,8 +8,8 @@ import (
 	adminAPI "github.com/smallstep/certificates/authority/admin/api"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func addCommand() cli.Command {
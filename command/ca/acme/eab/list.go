@@ -10,8 +10,8 @@ import (
 	"github.com/smallstep/certificates/ca"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func listCommand() cli.Command {
@@ -6,8 +6,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func removeCommand() cli.Command {
@@ -7,7 +7,7 @@ import (
 	"github.com/smallstep/certificates/ca"
 	"github.com/urfave/cli"
 
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 	"go.step.sm/cli-utils/ui"
 	"go.step.sm/linkedca"
 )
@@ -7,8 +7,8 @@ import (
 
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/linkedca"
 )
 
@@ -25,7 +25,7 @@ import (
 	// Enable azurekms
 	_ "github.com/smallstep/certificates/kms/azurekms"
 
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 	"go.step.sm/cli-utils/step"
 	"go.step.sm/cli-utils/ui"
 )
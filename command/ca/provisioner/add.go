@@ -1,10 +1,12 @@
 package provisioner
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"net/url"
 	"os"
@@ -17,9 +19,10 @@ import (
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/jose"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
+	"golang.org/x/term"
 )
 
 func addCommand() cli.Command {
@@ -29,6 +32,7 @@ func addCommand() cli.Command {
 		Usage:  "add one or more provisioners to the CA configuration",
 		UsageText: `**step ca provisioner add** <name> <jwk-file> [<jwk-file> ...]
 **--ca-config**=<file> [**--type**=JWK]  [**--create**] [**--password-file**=<file>]
+[**--no-backup**] [**--lock-timeout**=<duration>]
 
 **step ca provisioner add** <name> **--type**=OIDC **--ca-config**=<file>
 [**--client-id**=<id>] [**--client-secret**=<secret>]
@@ -51,6 +55,8 @@ func addCommand() cli.Command {
 **step ca provisioner add** <name> **--type**=ACME **--ca-config**=<file>`,
 		Flags: []cli.Flag{
 			flags.CaConfig,
+			noBackupFlag,
+			lockTimeoutFlag,
 			cli.StringFlag{
 				Name:  "type",
 				Value: provisioner.TypeJWK.String(),
@@ -200,10 +206,19 @@ keys and x509 Certificates.`,
 		},
 		Description: `**step ca provisioner add** adds one or more provisioners
 to the configuration and writes the new configuration back to the CA config.
+Before modifying the CA config, a backup is written to <file>.bak.<timestamp>
+unless **--no-backup** is used. The CA config is locked while it is modified,
+if another process holds the lock the command waits up to **--lock-timeout**.
 
 To pick up the new configuration you must SIGHUP (kill -1 <pid>) or restart the
 step-ca process.
 
+If the standard input is a terminal, and neither **--type**, **--create**, nor
+any <jwk-file> is given, the command will prompt for the type of provisioner
+and for any required flag of that type that is missing. For a JWK provisioner,
+it prompts to generate a new key pair or for the path of a JWK file. Otherwise,
+the type defaults to JWK.
+
 ## POSITIONAL ARGUMENTS
 
 <name>
@@ -211,7 +226,8 @@ step-ca process.
 will be linked to all the keys.
 
 <jwk-path>
-: List of private (or public) keys in JWK or PEM format.
+: List of private (or public) keys in JWK or PEM format. Use '-' to read a JWK
+or a JWK set from the standard input.
 
 ## EXAMPLES
 
@@ -220,6 +236,11 @@ Add a single JWK provisioner:
 $ step ca provisioner add max@smallstep.com ./max-laptop.jwk --ca-config ca.json
 '''
 
+Add a single JWK provisioner reading the public key from the standard input:
+'''
+$ step crypto jwk public < max-laptop.jwk | step ca provisioner add max@smallstep.com - --ca-config ca.json
+'''
+
 Add a single JWK provisioner using an auto-generated asymmetric key pair:
 '''
 $ step ca provisioner add max@smallstep.com --ca-config ca.json \
@@ -332,6 +353,26 @@ func addAction(ctx *cli.Context) (err error) {
 		return errs.RequiredFlag(ctx, "ca-config")
 	}
 
+	if err = checkConfig(caCfg); err != nil {
+		return err
+	}
+
+	// Prompt before locking the configuration, only if the arguments do not
+	// already describe a JWK provisioner.
+	jwkFiles := args[1:]
+	if !ctx.IsSet("type") && ctx.NArg() == 1 && !ctx.Bool("create") &&
+		term.IsTerminal(int(os.Stdin.Fd())) {
+		if jwkFiles, err = promptProvisionerFlags(ctx, uiPrompter{}); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := lockConfig(ctx, caCfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	c, err := config.LoadConfiguration(caCfg)
 	if err != nil {
 		return errors.Wrapf(err, "error loading configuration")
@@ -350,7 +391,7 @@ func addAction(ctx *cli.Context) (err error) {
 	var list provisioner.List
 	switch typ {
 	case provisioner.TypeJWK:
-		list, err = addJWKProvisioner(ctx, name, provMap)
+		list, err = addJWKProvisioner(ctx, name, jwkFiles, provMap)
 	case provisioner.TypeOIDC:
 		list, err = addOIDCProvisioner(ctx, name, provMap)
 	case provisioner.TypeAWS:
@@ -376,7 +417,7 @@ func addAction(ctx *cli.Context) (err error) {
 	}
 
 	c.AuthorityConfig.Provisioners = append(c.AuthorityConfig.Provisioners, list...)
-	if err := c.Save(caCfg); err != nil {
+	if err := saveConfig(ctx, c, caCfg); err != nil {
 		return err
 	}
 
@@ -385,7 +426,7 @@ func addAction(ctx *cli.Context) (err error) {
 	return nil
 }
 
-func addJWKProvisioner(ctx *cli.Context, name string, provMap map[string]bool) (list provisioner.List, err error) {
+func addJWKProvisioner(ctx *cli.Context, name string, jwkFiles []string, provMap map[string]bool) (list provisioner.List, err error) {
 	var password string
 	if passwordFile := ctx.String("password-file"); len(passwordFile) > 0 {
 		password, err = utils.ReadStringPasswordFromFile(passwordFile)
@@ -395,7 +436,7 @@ func addJWKProvisioner(ctx *cli.Context, name string, provMap map[string]bool) (
 	}
 
 	if ctx.Bool("create") {
-		if ctx.NArg() > 1 {
+		if len(jwkFiles) > 0 {
 			return nil, errs.IncompatibleFlag(ctx, "create", "<jwk-path> positional arg")
 		}
 		pass, err := ui.PromptPasswordGenerate("Please enter a password to encrypt the provisioner private key? [leave empty and we'll generate one]", ui.WithValue(password))
@@ -430,56 +471,66 @@ func addJWKProvisioner(ctx *cli.Context, name string, provMap map[string]bool) (
 	}
 
 	// Add multiple provisioners using JWK files.
-	if ctx.NArg() < 2 {
+	if len(jwkFiles) == 0 {
 		return nil, errs.TooFewArguments(ctx)
 	}
 
-	jwkFiles := ctx.Args()[1:]
+	var stdin bool
 	for _, filename := range jwkFiles {
-		jwk, err := jose.ParseKey(filename)
-		if err != nil {
-			return nil, errs.FileError(err, filename)
-		}
-		// Only use asymmetric cryptography
-		if _, ok := jwk.Key.([]byte); ok {
-			return nil, errors.New("invalid JWK: a symmetric key cannot be used as a provisioner")
-		}
-		// Create kid if not present
-		if jwk.KeyID == "" {
-			jwk.KeyID, err = jose.Thumbprint(jwk)
-			if err != nil {
-				return nil, err
+		if filename == "-" {
+			if stdin {
+				return nil, errors.New("the standard input '-' can only be used once")
 			}
+			stdin = true
 		}
-		key := jwk.Public()
-
-		// Initialize provisioner and check for duplicates
-		p := &provisioner.JWK{
-			Type:   provisioner.TypeJWK.String(),
-			Name:   name,
-			Key:    &key,
-			Claims: getClaims(ctx),
-		}
-		if _, ok := provMap[p.GetIDForToken()]; !ok {
-			provMap[p.GetIDForToken()] = true
-		} else {
-			return nil, errors.Errorf("duplicated provisioner: CA config already contains a provisioner with name=%s and kid=%s", name, jwk.KeyID)
+	}
+	for _, filename := range jwkFiles {
+		jwks, err := readProvisionerJWKs(filename)
+		if err != nil {
+			return nil, err
 		}
-
-		// Encrypt JWK
-		if !jwk.IsPublic() {
-			jwe, err := jose.EncryptJWK(jwk)
-			if err != nil {
-				return nil, err
+		for _, jwk := range jwks {
+			// Only use asymmetric cryptography
+			if _, ok := jwk.Key.([]byte); ok {
+				return nil, errors.New("invalid JWK: a symmetric key cannot be used as a provisioner")
 			}
-			encryptedKey, err := jwe.CompactSerialize()
-			if err != nil {
-				return nil, errors.Wrap(err, "error serializing private key")
+			// Create kid if not present
+			if jwk.KeyID == "" {
+				jwk.KeyID, err = jose.Thumbprint(jwk)
+				if err != nil {
+					return nil, err
+				}
+			}
+			key := jwk.Public()
+
+			// Initialize provisioner and check for duplicates
+			p := &provisioner.JWK{
+				Type:   provisioner.TypeJWK.String(),
+				Name:   name,
+				Key:    &key,
+				Claims: getClaims(ctx),
+			}
+			if _, ok := provMap[p.GetIDForToken()]; !ok {
+				provMap[p.GetIDForToken()] = true
+			} else {
+				return nil, errors.Errorf("duplicated provisioner: CA config already contains a provisioner with name=%s and kid=%s", name, jwk.KeyID)
 			}
-			p.EncryptedKey = encryptedKey
-		}
 
-		list = append(list, p)
+			// Encrypt JWK
+			if !jwk.IsPublic() {
+				jwe, err := jose.EncryptJWK(jwk)
+				if err != nil {
+					return nil, err
+				}
+				encryptedKey, err := jwe.CompactSerialize()
+				if err != nil {
+					return nil, errors.Wrap(err, "error serializing private key")
+				}
+				p.EncryptedKey = encryptedKey
+			}
+
+			list = append(list, p)
+		}
 	}
 	return list, nil
 }
@@ -760,6 +811,43 @@ func getClaims(ctx *cli.Context) *provisioner.Claims {
 	return nil
 }
 
+// readProvisionerJWKs returns the key in the given JWK or PEM file. If the
+// filename is "-", a JWK or a JWK set is read from the standard input.
+func readProvisionerJWKs(filename string) ([]*jose.JSONWebKey, error) {
+	if filename != "-" {
+		jwk, err := jose.ParseKey(filename)
+		if err != nil {
+			return nil, errs.FileError(err, filename)
+		}
+		return []*jose.JSONWebKey{jwk}, nil
+	}
+
+	b, err := utils.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if b = bytes.TrimSpace(b); len(b) == 0 {
+		return nil, errors.New("error reading the standard input: no JWK found")
+	}
+	if b, err = jose.Decrypt("Please enter the password to decrypt the JWK", b); err != nil {
+		return nil, err
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(b, &jwks); err == nil && len(jwks.Keys) > 0 {
+		keys := make([]*jose.JSONWebKey, len(jwks.Keys))
+		for i := range jwks.Keys {
+			keys[i] = &jwks.Keys[i]
+		}
+		return keys, nil
+	}
+	jwk := new(jose.JSONWebKey)
+	if err := json.Unmarshal(b, jwk); err != nil {
+		return nil, errors.New("error reading the standard input: unsupported format, a JWK or JWK set is expected")
+	}
+	return []*jose.JSONWebKey{jwk}, nil
+}
+
 func parseInstanceAge(ctx *cli.Context) (provisioner.Duration, error) {
 	age := ctx.Duration("instance-age")
 	if age == 0 {
@@ -771,6 +859,91 @@ func parseInstanceAge(ctx *cli.Context) (provisioner.Duration, error) {
 	return provisioner.Duration{Duration: age}, nil
 }
 
+// provisionerTypeRequiredFlags lists the flags that must be set to add a
+// provisioner of a given type. A JWK provisioner requires --create or a JWK
+// file.
+var provisionerTypeRequiredFlags = map[provisioner.Type][]string{
+	provisioner.TypeJWK:   {"create"},
+	provisioner.TypeOIDC:  {"client-id", "configuration-endpoint"},
+	provisioner.TypeAzure: {"azure-tenant"},
+	provisioner.TypeX5C:   {"x5c-root"},
+	provisioner.TypeK8sSA: {"pem-keys"},
+}
+
+// prompter asks the questions of promptProvisionerFlags.
+type prompter interface {
+	Select(label string, items []string) (int, error)
+	Prompt(label string) (string, error)
+}
+
+// uiPrompter is the prompter using the terminal.
+type uiPrompter struct{}
+
+func (uiPrompter) Select(label string, items []string) (int, error) {
+	i, _, err := ui.Select(label, items)
+	return i, err
+}
+
+func (uiPrompter) Prompt(label string) (string, error) {
+	return ui.Prompt(label, ui.WithValidateNotEmpty())
+}
+
+// promptProvisionerFlags prompts for the provisioner type and the flags
+// required by that type that are not set. For a JWK provisioner, it prompts
+// to generate a key pair or for a JWK file, and returns the JWK files to add.
+func promptProvisionerFlags(ctx *cli.Context, p prompter) ([]string, error) {
+	types := []provisioner.Type{
+		provisioner.TypeJWK, provisioner.TypeOIDC, provisioner.TypeAWS,
+		provisioner.TypeGCP, provisioner.TypeAzure, provisioner.TypeACME,
+		provisioner.TypeX5C, provisioner.TypeK8sSA, provisioner.TypeSSHPOP,
+	}
+	names := make([]string, len(types))
+	for i, typ := range types {
+		names[i] = typ.String()
+	}
+	i, err := p.Select("What type of provisioner do you want to add?", names)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Set("type", names[i]); err != nil {
+		return nil, err
+	}
+
+	var jwkFiles []string
+	for _, name := range provisionerTypeRequiredFlags[types[i]] {
+		switch {
+		case name == "create":
+			j, err := p.Select("How do you want to set the key of the provisioner?",
+				[]string{"Generate a new key pair", "Use an existing JWK file"})
+			if err != nil {
+				return nil, err
+			}
+			if j == 0 {
+				if err := ctx.Set("create", "true"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			filename, err := p.Prompt("What is the path of the JWK file?")
+			if err != nil {
+				return nil, err
+			}
+			jwkFiles = append(jwkFiles, filename)
+		case ctx.String(name) != "":
+			continue
+		default:
+			value, err := p.Prompt("What is the value of --" + name + "?")
+			if err != nil {
+				return nil, err
+			}
+			if err := ctx.Set(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return jwkFiles, nil
+}
+
 func parseProvisionerType(ctx *cli.Context) (provisioner.Type, error) {
 	typ := ctx.String("type")
 	switch strings.ToLower(typ) {
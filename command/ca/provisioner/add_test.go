@@ -0,0 +1,77 @@
+package provisioner
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+// stubPrompter is a prompter returning the given answers in order.
+type stubPrompter struct {
+	selects []int
+	prompts []string
+}
+
+func (p *stubPrompter) Select(label string, items []string) (int, error) {
+	i := p.selects[0]
+	p.selects = p.selects[1:]
+	return i, nil
+}
+
+func (p *stubPrompter) Prompt(label string) (string, error) {
+	s := p.prompts[0]
+	p.prompts = p.prompts[1:]
+	return s, nil
+}
+
+func TestPromptProvisionerFlags(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		prompter     *stubPrompter
+		wantType     string
+		wantCreate   bool
+		wantJWKFiles []string
+		wantFlags    map[string]string
+	}{
+		{"jwk create", nil, &stubPrompter{selects: []int{0, 0}}, "JWK", true, nil, nil},
+		{"jwk file", nil, &stubPrompter{selects: []int{0, 1}, prompts: []string{"key.pub"}}, "JWK", false, []string{"key.pub"}, nil},
+		{"oidc", nil, &stubPrompter{selects: []int{1}, prompts: []string{"client", "https://example.com"}}, "OIDC", false, nil,
+			map[string]string{"client-id": "client", "configuration-endpoint": "https://example.com"}},
+		{"oidc with flag", []string{"--client-id", "foo"}, &stubPrompter{selects: []int{1}, prompts: []string{"https://example.com"}}, "OIDC", false, nil,
+			map[string]string{"client-id": "foo", "configuration-endpoint": "https://example.com"}},
+		{"acme", nil, &stubPrompter{selects: []int{5}}, "ACME", false, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := flag.NewFlagSet("test", 0)
+			for _, f := range addCommand().Flags {
+				f.Apply(set)
+			}
+			if err := set.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			ctx := cli.NewContext(&cli.App{}, set, nil)
+			jwkFiles, err := promptProvisionerFlags(ctx, tt.prompter)
+			if err != nil {
+				t.Fatalf("promptProvisionerFlags() error = %v", err)
+			}
+			if got := ctx.String("type"); got != tt.wantType {
+				t.Errorf("type = %q, want %q", got, tt.wantType)
+			}
+			if got := ctx.Bool("create"); got != tt.wantCreate {
+				t.Errorf("create = %v, want %v", got, tt.wantCreate)
+			}
+			if !reflect.DeepEqual(jwkFiles, tt.wantJWKFiles) {
+				t.Errorf("promptProvisionerFlags() = %v, want %v", jwkFiles, tt.wantJWKFiles)
+			}
+			for name, want := range tt.wantFlags {
+				if got := ctx.String(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
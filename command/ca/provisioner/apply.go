@@ -0,0 +1,193 @@
+package provisioner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority"
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/cli-utils/ui"
+)
+
+func applyCommand() cli.Command {
+	return cli.Command{
+		Name:   "apply",
+		Action: cli.ActionFunc(applyAction),
+		Usage:  "make the provisioners in the CA match a file of provisioners",
+		UsageText: `**step ca provisioner apply** <file> [**--prune**] [**--auto-approve**]
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name: "prune",
+				Usage: `Remove the provisioners in the CA that are not in the file. By default they are
+kept.`,
+			},
+			cli.BoolFlag{
+				Name:  "auto-approve, yes",
+				Usage: `Apply the changes without asking for confirmation.`,
+			},
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step ca provisioner apply** makes the provisioners in the CA match the
+desired state in a file using the admin API. The CA must have remote
+provisioner management enabled.
+
+The changes are computed like in **step ca provisioner diff**: provisioners only
+in the file are created, and provisioners with different properties are
+updated. Provisioners only in the CA are removed if **--prune** is used. The
+plan is printed and confirmation is requested before applying it, unless
+**--auto-approve** is used.
+
+The changes are applied in order and the command does not stop on the first
+error, the result of each change is printed, and the command fails if any of
+them failed.
+
+## POSITIONAL ARGUMENTS
+
+<file>
+: The <file> with the JSON array of provisioners, using the format of the
+"provisioners" property in ca.json or the output of **step ca provisioner list**.
+A hyphen ("-") indicates STDIN as <file>.
+
+## EXAMPLES
+
+Create and update the provisioners in the CA using a file:
+'''
+$ step ca provisioner apply provisioners.json
+'''
+
+Make the CA match the file, removing the provisioners not in it, without
+asking for confirmation:
+'''
+$ step ca provisioner apply provisioners.json --prune --auto-approve
+'''`,
+	}
+}
+
+func applyAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+		return err
+	}
+
+	desired, err := readProvisionersFile(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+	live, err := client.GetProvisioners()
+	if err != nil {
+		return errors.Wrap(err, "error getting the provisioners")
+	}
+
+	changes, err := diffProvisioners(live, desired)
+	if err != nil {
+		return err
+	}
+	if !ctx.Bool("prune") {
+		changes = withoutDeletions(changes)
+	}
+
+	printProvisionerChanges(os.Stdout, changes)
+	if len(changes) == 0 {
+		return nil
+	}
+	if !ctx.Bool("auto-approve") {
+		ok, err := ui.PromptYesNo(fmt.Sprintf("Apply %d changes? [y/n]", len(changes)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("operation canceled")
+		}
+	}
+
+	byName := make(map[string]provisioner.Interface, len(desired))
+	for _, p := range desired {
+		byName[p.GetName()] = p
+	}
+
+	var failed int
+	for _, c := range changes {
+		if err := applyProvisionerChange(client, c, byName[c.Name]); err != nil {
+			failed++
+			ui.Printf("✖ %s %s: %v\n", c.Action, c.Name, err)
+		} else {
+			ui.Printf("✔ %s %s\n", c.Action, c.Name)
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d changes failed", failed, len(changes))
+	}
+	return nil
+}
+
+// withoutDeletions returns the changes that do not remove a provisioner.
+func withoutDeletions(changes []provisionerChange) []provisionerChange {
+	filtered := []provisionerChange{}
+	for _, c := range changes {
+		if c.Action != diffActionDelete {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// applyProvisionerChange applies a change using the admin API, p is the
+// desired provisioner, and it is nil for deletions.
+func applyProvisionerChange(client *ca.AdminClient, c provisionerChange, p provisioner.Interface) error {
+	switch c.Action {
+	case diffActionAdd:
+		prov, err := authority.ProvisionerToLinkedca(p)
+		if err != nil {
+			return err
+		}
+		// The file can come from another CA, the new provisioner must not
+		// keep its identifiers and timestamps.
+		prov.Id = ""
+		prov.AuthorityId = ""
+		prov.CreatedAt = nil
+		prov.DeletedAt = nil
+		_, err = client.CreateProvisioner(prov)
+		return err
+	case diffActionUpdate:
+		current, err := client.GetProvisioner(ca.WithProvisionerName(c.Name))
+		if err != nil {
+			return err
+		}
+		prov, err := authority.ProvisionerToLinkedca(p)
+		if err != nil {
+			return err
+		}
+		prov.Id = current.Id
+		prov.AuthorityId = current.AuthorityId
+		prov.CreatedAt = current.CreatedAt
+		return client.UpdateProvisioner(c.Name, prov)
+	case diffActionDelete:
+		return client.RemoveProvisioner(ca.WithProvisionerName(c.Name))
+	default:
+		return errors.Errorf("unsupported action %s", c.Action)
+	}
+}
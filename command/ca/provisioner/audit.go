@@ -0,0 +1,206 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/certificates/pki"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+func auditCommand() cli.Command {
+	return cli.Command{
+		Name:   "audit",
+		Action: cli.ActionFunc(auditAction),
+		Usage:  "review the provisioners configured in the CA for risky settings",
+		UsageText: `**step ca provisioner audit** [**--format**=<format>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: `The output format for printing the findings.
+
+: <format> is a string and must be one of:
+
+    **text**
+    :  Print a table suitable for a human to read. (default)
+
+    **json**
+    :  Print output in JSON format.`,
+			},
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step ca provisioner audit** reviews the provisioners configured in the CA
+and prints a report with the settings that weaken the security of the CA.
+
+The checks performed are:
+
+* **high**: AWS, GCP and Azure provisioners with trust on first use disabled,
+  an instance identity document can be used to get certificates more than once.
+* **high**: SCEP provisioners using DES-CBC to encrypt the responses.
+* **medium**: ACME provisioners not requiring external account binding (EAB).
+* **medium**: provisioners allowing the renewal of expired certificates.
+* **medium**: provisioners with a maximum duration longer than 90 days for
+  x509 certificates, 7 days for ssh user certificates, or 365 days for ssh host
+  certificates.
+
+The claims not set in a provisioner use the global configuration of the CA,
+and they are not reviewed.
+
+The command exits with status code 1 if any high severity finding is found.
+
+## EXAMPLES
+
+Review the provisioners in the CA:
+'''
+$ step ca provisioner audit
+'''
+
+Print the findings in JSON format:
+'''
+$ step ca provisioner audit --format json
+'''`,
+	}
+}
+
+// Severities of the audit findings.
+const (
+	severityHigh   = "high"
+	severityMedium = "medium"
+)
+
+// Maximum durations allowed by the audit.
+const (
+	auditMaxTLSDur      = 90 * 24 * time.Hour
+	auditMaxUserSSHDur  = 7 * 24 * time.Hour
+	auditMaxHostSSHDur  = 365 * 24 * time.Hour
+	scepDESCBCAlgorithm = 0
+)
+
+// auditFinding is a risky setting found in a provisioner.
+type auditFinding struct {
+	Severity    string `json:"severity"`
+	Provisioner string `json:"provisioner"`
+	Type        string `json:"type"`
+	Message     string `json:"message"`
+}
+
+func auditAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 0); err != nil {
+		return err
+	}
+
+	format := ctx.String("format")
+	if format != "json" && format != "text" {
+		return errs.InvalidFlagValue(ctx, "format", format, "text, json")
+	}
+
+	caURL, err := flags.ParseCaURL(ctx)
+	if err != nil {
+		return err
+	}
+	provisioners, err := pki.GetProvisioners(caURL, ctx.String("root"))
+	if err != nil {
+		return errors.Wrap(err, "error getting the provisioners")
+	}
+
+	findings := auditProvisioners(provisioners)
+	if format == "json" {
+		b, err := json.MarshalIndent(findings, "", "   ")
+		if err != nil {
+			return errors.Wrap(err, "error marshaling findings")
+		}
+		fmt.Println(string(b))
+	} else if err := printAuditFindings(findings, len(provisioners)); err != nil {
+		return err
+	}
+
+	var high int
+	for _, f := range findings {
+		if f.Severity == severityHigh {
+			high++
+		}
+	}
+	if high > 0 {
+		return errs.NewExitError(errors.Errorf("found %d high severity issues", high), 1)
+	}
+	return nil
+}
+
+// auditProvisioners returns the risky settings found in the given
+// provisioners.
+func auditProvisioners(provisioners provisioner.List) []auditFinding {
+	findings := []auditFinding{}
+	for _, p := range provisioners {
+		add := func(severity, format string, args ...interface{}) {
+			findings = append(findings, auditFinding{
+				Severity:    severity,
+				Provisioner: p.GetName(),
+				Type:        p.GetType().String(),
+				Message:     fmt.Sprintf(format, args...),
+			})
+		}
+
+		if _, disableTOFU, ok := cloudProvisionerOptions(p); ok && disableTOFU {
+			add(severityHigh, "trust on first use is disabled")
+		}
+		switch p := p.(type) {
+		case *provisioner.SCEP:
+			if p.EncryptionAlgorithmIdentifier == scepDESCBCAlgorithm {
+				add(severityHigh, "responses are encrypted using DES-CBC")
+			}
+		case *provisioner.ACME:
+			if !p.RequireEAB {
+				add(severityMedium, "external account binding is not required")
+			}
+		}
+
+		claims := provisionerClaims(p)
+		if claims == nil {
+			continue
+		}
+		if claims.AllowRenewalAfterExpiry != nil && *claims.AllowRenewalAfterExpiry {
+			add(severityMedium, "renewal of expired certificates is allowed")
+		}
+		for _, d := range []struct {
+			name  string
+			value *provisioner.Duration
+			limit time.Duration
+		}{
+			{"x509", claims.MaxTLSDur, auditMaxTLSDur},
+			{"ssh user", claims.MaxUserSSHDur, auditMaxUserSSHDur},
+			{"ssh host", claims.MaxHostSSHDur, auditMaxHostSSHDur},
+		} {
+			if d.value != nil && d.value.Duration > d.limit {
+				add(severityMedium, "maximum %s certificate duration is %s, longer than %s", d.name, d.value.Duration, d.limit)
+			}
+		}
+	}
+	return findings
+}
+
+func printAuditFindings(findings []auditFinding, total int) error {
+	if len(findings) == 0 {
+		fmt.Printf("No issues found in %d provisioners.\n", total)
+		return nil
+	}
+
+	w := new(tabwriter.Writer)
+	// Format in tab-separated columns with a tab stop of 8.
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(w, "SEVERITY\tPROVISIONER\tTYPE\tFINDING")
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Severity, f.Provisioner, f.Type, f.Message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,46 @@
+package provisioner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/smallstep/certificates/authority/provisioner"
+)
+
+func TestAuditProvisioners(t *testing.T) {
+	yes := true
+	list := provisioner.List{
+		&provisioner.JWK{Name: "jwk", Type: "JWK"},
+		&provisioner.JWK{Name: "long", Type: "JWK", Claims: &provisioner.Claims{
+			MaxTLSDur:               &provisioner.Duration{Duration: 100 * 24 * time.Hour},
+			MaxHostSSHDur:           &provisioner.Duration{Duration: 30 * 24 * time.Hour},
+			AllowRenewalAfterExpiry: &yes,
+		}},
+		&provisioner.AWS{Name: "aws", Type: "AWS", DisableTrustOnFirstUse: true},
+		&provisioner.GCP{Name: "gcp", Type: "GCP"},
+		&provisioner.ACME{Name: "acme", Type: "ACME"},
+		&provisioner.ACME{Name: "acme-eab", Type: "ACME", RequireEAB: true},
+		&provisioner.SCEP{Name: "scep", Type: "SCEP"},
+		&provisioner.SCEP{Name: "scep-aes", Type: "SCEP", EncryptionAlgorithmIdentifier: 2},
+	}
+
+	var got []string
+	for _, f := range auditProvisioners(list) {
+		got = append(got, f.Severity+" "+f.Provisioner)
+	}
+	want := []string{
+		"medium long",
+		"medium long",
+		"high aws",
+		"medium acme",
+		"high scep",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("auditProvisioners() = %v, want %v", got, want)
+	}
+
+	if got := auditProvisioners(nil); got == nil || len(got) != 0 {
+		t.Errorf("auditProvisioners(nil) = %v, want empty list", got)
+	}
+}
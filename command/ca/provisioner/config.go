@@ -0,0 +1,151 @@
+package provisioner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/config"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/smallstep/cli/utils/sysutils"
+	"github.com/urfave/cli"
+	"go.step.sm/cli-utils/ui"
+)
+
+var noBackupFlag = cli.BoolFlag{
+	Name: "no-backup",
+	Usage: `Do not create a backup of the CA configuration before modifying it. By
+default, the current configuration is copied to <file>.bak.<timestamp>.`,
+}
+
+var lockTimeoutFlag = cli.DurationFlag{
+	Name: "lock-timeout",
+	Usage: `The maximum <duration> to wait for the lock on the CA configuration. The file
+is locked while it is read and modified to prevent concurrent changes.`,
+	Value: 10 * time.Second,
+}
+
+// lockConfigInterval is the time between two attempts to lock the CA
+// configuration.
+const lockConfigInterval = 100 * time.Millisecond
+
+// lockConfig acquires an exclusive advisory lock on the CA configuration,
+// waiting up to the duration in the --lock-timeout flag. The returned function
+// releases the lock.
+func lockConfig(ctx *cli.Context, filename string) (func() error, error) {
+	return lockFile(filename, ctx.Duration("lock-timeout"))
+}
+
+// lockFile acquires an exclusive advisory lock on filename, waiting up to the
+// given timeout. The returned function releases the lock and closes the file.
+func lockFile(filename string, timeout time.Duration) (func() error, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR, 0)
+	if err != nil {
+		return nil, errs.FileError(err, filename)
+	}
+	fd := int(f.Fd())
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = sysutils.FileLock(fd)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, errors.Wrapf(err, "error locking %s", filename)
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, errors.Errorf("error locking %s: the file is locked by another process, "+
+				"try again later or increase --lock-timeout", filename)
+		}
+		time.Sleep(lockConfigInterval)
+	}
+
+	return func() error {
+		if err := sysutils.FileUnlock(fd); err != nil {
+			f.Close()
+			return errors.Wrapf(err, "error unlocking %s", filename)
+		}
+		return errors.Wrapf(f.Close(), "error closing %s", filename)
+	}, nil
+}
+
+// checkConfig checks that filename exists and that it has the minimal schema
+// of a CA configuration, an object with an "authority" object with an optional
+// "provisioners" array. It returns errors easier to understand than the ones
+// returned when the configuration is loaded.
+func checkConfig(filename string) error {
+	st, err := os.Stat(filename)
+	if err != nil {
+		return errs.FileError(err, filename)
+	}
+	if st.IsDir() {
+		return errors.Errorf("error reading %s: the path is a directory, expected a CA configuration file", filename)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return errs.FileError(err, filename)
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := 1 + bytes.Count(b[:syntaxErr.Offset], []byte("\n"))
+			return errors.Errorf("error reading %s: invalid JSON on line %d: %v", filename, line, err)
+		}
+		return errors.Wrapf(err, "error reading %s", filename)
+	}
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return errors.Errorf("error reading %s: expected an object with authority.provisioners", filename)
+	}
+	authority, ok := root["authority"].(map[string]interface{})
+	if !ok {
+		return errors.Errorf("error reading %s: expected an object with authority.provisioners", filename)
+	}
+	if provisioners, ok := authority["provisioners"]; ok && provisioners != nil {
+		if _, ok := provisioners.([]interface{}); !ok {
+			return errors.Errorf("error reading %s: authority.provisioners must be an array", filename)
+		}
+	}
+	return nil
+}
+
+// saveConfig writes the given configuration to filename. Unless the
+// --no-backup flag is set, a copy of the current file is written first, and
+// it is restored if the new configuration cannot be written.
+func saveConfig(ctx *cli.Context, c *config.Config, filename string) error {
+	if ctx.Bool("no-backup") {
+		return c.Save(filename)
+	}
+
+	st, err := os.Stat(filename)
+	if err != nil {
+		return errs.FileError(err, filename)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return errs.FileError(err, filename)
+	}
+	backup := fmt.Sprintf("%s.bak.%d", filename, time.Now().Unix())
+	if err := os.WriteFile(backup, b, st.Mode().Perm()); err != nil {
+		return errs.FileError(err, backup)
+	}
+
+	if err := c.Save(filename); err != nil {
+		if rerr := os.WriteFile(filename, b, st.Mode().Perm()); rerr != nil {
+			return errors.Wrapf(err, "error restoring %s from %s", filename, backup)
+		}
+		return errors.Wrapf(err, "the original configuration has been restored")
+	}
+
+	ui.Printf("A backup of the previous configuration has been saved in %s.\n", backup)
+	return nil
+}
@@ -0,0 +1,131 @@
+package provisioner
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ca.json")
+	if err := os.WriteFile(filename, []byte("0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two concurrent writers incrementing the counter in the file, without
+	// the lock some of the increments would be lost.
+	const writers, increments = 2, 50
+	var wg sync.WaitGroup
+	errc := make(chan error, writers*increments)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				unlock, err := lockFile(filename, 10*time.Second)
+				if err != nil {
+					errc <- err
+					return
+				}
+				b, err := os.ReadFile(filename)
+				if err == nil {
+					var n int
+					if n, err = strconv.Atoi(string(b)); err == nil {
+						time.Sleep(time.Millisecond)
+						err = os.WriteFile(filename, []byte(strconv.Itoa(n+1)), 0600)
+					}
+				}
+				if err != nil {
+					errc <- err
+				}
+				if err := unlock(); err != nil {
+					errc <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != strconv.Itoa(writers*increments) {
+		t.Errorf("counter = %s, want %d", got, writers*increments)
+	}
+}
+
+func TestLockFile_timeout(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ca.json")
+	if err := os.WriteFile(filename, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockFile(filename, time.Second)
+	if err != nil {
+		t.Fatalf("lockFile() error = %v", err)
+	}
+	if _, err := lockFile(filename, 200*time.Millisecond); err == nil {
+		t.Error("lockFile() error = nil, want timeout error")
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock() error = %v", err)
+	}
+
+	unlock, err = lockFile(filename, time.Second)
+	if err != nil {
+		t.Fatalf("lockFile() error = %v", err)
+	}
+	if err := unlock(); err != nil {
+		t.Errorf("unlock() error = %v", err)
+	}
+	if _, err := lockFile(filepath.Join(t.TempDir(), "missing.json"), time.Second); err == nil {
+		t.Error("lockFile() error = nil, want file error")
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"ok", `{"root": "root.crt", "authority": {"provisioners": []}}`, ""},
+		{"ok no provisioners", `{"authority": {}}`, ""},
+		{"invalid json", "{\n  \"authority\": {,\n}", "invalid JSON on line 2"},
+		{"array", `[]`, "expected an object with authority.provisioners"},
+		{"no authority", `{"root": "root.crt"}`, "expected an object with authority.provisioners"},
+		{"bad provisioners", `{"authority": {"provisioners": {}}}`, "authority.provisioners must be an array"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, strconv.Itoa(i)+".json")
+			if err := os.WriteFile(filename, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			err := checkConfig(filename)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("checkConfig() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("checkConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := checkConfig(filepath.Join(dir, "missing.json")); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("checkConfig() error = %v, want missing file error", err)
+	}
+	if err := checkConfig(dir); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("checkConfig() error = %v, want directory error", err)
+	}
+}
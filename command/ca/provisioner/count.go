@@ -0,0 +1,58 @@
+package provisioner
+
+import (
+	"fmt"
+
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+func countCommand() cli.Command {
+	return cli.Command{
+		Name:   "count",
+		Action: cli.ActionFunc(countAction),
+		Usage:  "print the number of provisioners configured in the CA",
+		UsageText: `**step ca provisioner count** [**--type**=<type>...] [**--filter**=<substring>]
+[**--disabled-only**] [**--enabled-only**] [**--ca-config**=<file>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			typeFilterFlag,
+			nameFilterFlag,
+			disabledOnlyFlag,
+			enabledOnlyFlag,
+			flags.CaConfig,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step ca provisioner count** prints the number of provisioners
+configured in the CA.
+
+## EXAMPLES
+
+Print the number of provisioners:
+'''
+$ step ca provisioner count
+'''
+
+Print the number of ACME provisioners:
+'''
+$ step ca provisioner count --type acme
+'''`,
+	}
+}
+
+func countAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 0); err != nil {
+		return err
+	}
+
+	provisioners, _, _, err := getFilteredProvisioners(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(len(provisioners))
+	return nil
+}
@@ -0,0 +1,260 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/certificates/pki"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+func diffCommand() cli.Command {
+	return cli.Command{
+		Name:   "diff",
+		Action: cli.ActionFunc(diffAction),
+		Usage:  "compare a file of provisioners with the provisioners in the CA",
+		UsageText: `**step ca provisioner diff** <file> [**--format**=<format>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: `The output format for printing the changes.
+
+: <format> is a string and must be one of:
+
+    **text**
+    :  Print one line per change suitable for a human to read. (default)
+
+    **json**
+    :  Print output in JSON format.`,
+			},
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step ca provisioner diff** compares the desired state of the provisioners
+in a file with the provisioners configured in the CA, and prints the changes
+required to reconcile them. The CA is not modified.
+
+Provisioners are matched by name. A provisioner only in the file is added
+(**+**), a provisioner only in the CA is deleted (**-**), and a provisioner in
+both with different properties is updated (**~**), the properties that differ
+are printed with the change.
+
+## POSITIONAL ARGUMENTS
+
+<file>
+: The <file> with the JSON array of provisioners, using the format of the
+"provisioners" property in ca.json or the output of **step ca provisioner list**.
+A hyphen ("-") indicates STDIN as <file>.
+
+## EXAMPLES
+
+Compare a file with the provisioners in the CA:
+'''
+$ step ca provisioner diff provisioners.json
+'''
+
+Print the changes in JSON format:
+'''
+$ step ca provisioner diff provisioners.json --format json
+'''
+
+Compare the provisioners in two CAs:
+'''
+$ step ca provisioner list --context staging | \
+  step ca provisioner diff - --context production
+'''`,
+	}
+}
+
+// Actions of the provisioner changes.
+const (
+	diffActionAdd    = "add"
+	diffActionUpdate = "update"
+	diffActionDelete = "delete"
+)
+
+// provisionerChange is a change required to go from the provisioners in the CA
+// to the desired ones.
+type provisionerChange struct {
+	Action     string   `json:"action"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Properties []string `json:"properties,omitempty"`
+}
+
+func diffAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+		return err
+	}
+
+	format := ctx.String("format")
+	if format != "json" && format != "text" {
+		return errs.InvalidFlagValue(ctx, "format", format, "text, json")
+	}
+
+	desired, err := readProvisionersFile(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	caURL, err := flags.ParseCaURL(ctx)
+	if err != nil {
+		return err
+	}
+	live, err := pki.GetProvisioners(caURL, ctx.String("root"))
+	if err != nil {
+		return errors.Wrap(err, "error getting the provisioners")
+	}
+
+	changes, err := diffProvisioners(live, desired)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		b, err := json.MarshalIndent(changes, "", "   ")
+		if err != nil {
+			return errors.Wrap(err, "error marshaling changes")
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	printProvisionerChanges(os.Stdout, changes)
+	return nil
+}
+
+// readProvisionersFile reads the JSON array of provisioners in the given file.
+func readProvisionersFile(filename string) (provisioner.List, error) {
+	b, err := utils.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var provisioners provisioner.List
+	if err := json.Unmarshal(b, &provisioners); err != nil {
+		return nil, errors.Wrapf(err, "error reading %s", filename)
+	}
+	return provisioners, nil
+}
+
+// printProvisionerChanges prints one line per change.
+func printProvisionerChanges(w io.Writer, changes []provisionerChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	for _, c := range changes {
+		switch c.Action {
+		case diffActionAdd:
+			fmt.Fprintf(w, "+ %s (%s)\n", c.Name, c.Type)
+		case diffActionDelete:
+			fmt.Fprintf(w, "- %s (%s)\n", c.Name, c.Type)
+		default:
+			fmt.Fprintf(w, "~ %s (%s): %s\n", c.Name, c.Type, strings.Join(c.Properties, ", "))
+		}
+	}
+}
+
+// diffProvisioners returns the changes required to go from the live
+// provisioners to the desired ones. Additions and updates are returned in the
+// order of the desired provisioners, followed by the deletions in the order of
+// the live ones.
+func diffProvisioners(live, desired provisioner.List) ([]provisionerChange, error) {
+	liveProps, err := provisionerProperties(live)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the provisioners in the CA")
+	}
+	desiredProps, err := provisionerProperties(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []provisionerChange{}
+	for _, p := range desired {
+		name := p.GetName()
+		current, ok := liveProps[name]
+		if !ok {
+			changes = append(changes, provisionerChange{
+				Action: diffActionAdd,
+				Name:   name,
+				Type:   p.GetType().String(),
+			})
+			continue
+		}
+		if props := diffProperties(current, desiredProps[name]); len(props) > 0 {
+			changes = append(changes, provisionerChange{
+				Action:     diffActionUpdate,
+				Name:       name,
+				Type:       p.GetType().String(),
+				Properties: props,
+			})
+		}
+	}
+	for _, p := range live {
+		if _, ok := desiredProps[p.GetName()]; !ok {
+			changes = append(changes, provisionerChange{
+				Action: diffActionDelete,
+				Name:   p.GetName(),
+				Type:   p.GetType().String(),
+			})
+		}
+	}
+	return changes, nil
+}
+
+// provisionerProperties returns the JSON properties of each provisioner by
+// name. The provisioners are marshaled again so both sides of the diff use the
+// same representation, e.g. for durations.
+func provisionerProperties(provisioners provisioner.List) (map[string]map[string]interface{}, error) {
+	m := make(map[string]map[string]interface{}, len(provisioners))
+	for _, p := range provisioners {
+		name := p.GetName()
+		if name == "" {
+			return nil, errors.New("error reading provisioners: provisioner name cannot be empty")
+		}
+		if _, ok := m[name]; ok {
+			return nil, errors.Errorf("error reading provisioners: duplicated provisioner name %s", name)
+		}
+		b, err := json.Marshal(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error marshaling provisioner %s", name)
+		}
+		var props map[string]interface{}
+		if err := json.Unmarshal(b, &props); err != nil {
+			return nil, errors.Wrapf(err, "error marshaling provisioner %s", name)
+		}
+		m[name] = props
+	}
+	return m, nil
+}
+
+// diffProperties returns the sorted names of the properties that are
+// different.
+func diffProperties(a, b map[string]interface{}) []string {
+	var props []string
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !reflect.DeepEqual(v, w) {
+			props = append(props, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			props = append(props, k)
+		}
+	}
+	sort.Strings(props)
+	return props
+}
@@ -0,0 +1,67 @@
+package provisioner
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/smallstep/certificates/authority/provisioner"
+)
+
+func TestDiffProvisioners(t *testing.T) {
+	var live, desired provisioner.List
+	if err := json.Unmarshal([]byte(`[
+		{"type": "ACME", "name": "acme"},
+		{"type": "ACME", "name": "same", "claims": {"maxTLSCertDuration": "24h"}},
+		{"type": "OIDC", "name": "oidc", "clientID": "foo"},
+		{"type": "SSHPOP", "name": "sshpop"}
+	]`), &live); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`[
+		{"type": "ACME", "name": "same", "claims": {"maxTLSCertDuration": "24h0m0s"}},
+		{"type": "OIDC", "name": "oidc", "clientID": "bar", "domains": ["example.com"]},
+		{"type": "ACME", "name": "acme", "forceCN": true},
+		{"type": "X5C", "name": "x5c"}
+	]`), &desired); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := diffProvisioners(live, desired)
+	if err != nil {
+		t.Fatalf("diffProvisioners() error = %v", err)
+	}
+	want := []provisionerChange{
+		{Action: "update", Name: "oidc", Type: "OIDC", Properties: []string{"clientID", "domains"}},
+		{Action: "update", Name: "acme", Type: "ACME", Properties: []string{"forceCN"}},
+		{Action: "add", Name: "x5c", Type: "X5C"},
+		{Action: "delete", Name: "sshpop", Type: "SSHPOP"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffProvisioners() = %v, want %v", got, want)
+	}
+
+	if got, err := diffProvisioners(live, live); err != nil || len(got) != 0 {
+		t.Errorf("diffProvisioners() = %v, %v, want no changes", got, err)
+	}
+
+	desired = append(desired, desired[0])
+	if _, err := diffProvisioners(live, desired); err == nil {
+		t.Error("diffProvisioners() error = nil, want duplicated name error")
+	}
+}
+
+func TestWithoutDeletions(t *testing.T) {
+	changes := []provisionerChange{
+		{Action: "add", Name: "a"},
+		{Action: "delete", Name: "b"},
+		{Action: "update", Name: "c"},
+	}
+	want := []provisionerChange{
+		{Action: "add", Name: "a"},
+		{Action: "update", Name: "c"},
+	}
+	if got := withoutDeletions(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("withoutDeletions() = %v, want %v", got, want)
+	}
+}
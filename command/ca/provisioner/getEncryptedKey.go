@@ -1,13 +1,24 @@
 package provisioner
 
 import (
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/provisioner"
 	"github.com/smallstep/certificates/pki"
+	"github.com/smallstep/cli/crypto/pemutil"
 	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/jose"
+	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
+	"go.step.sm/cli-utils/ui"
 )
 
 func getEncryptedKeyCommand() cli.Command {
@@ -15,10 +26,23 @@ func getEncryptedKeyCommand() cli.Command {
 		Name:   "jwe-key",
 		Action: cli.ActionFunc(getEncryptedKeyAction),
 		Usage:  "retrieve and print a provisioning key in the CA",
-		UsageText: `**step ca provisioner jwe-key** <kid>
+		UsageText: `**step ca provisioner jwe-key** <kid|name> [**--format**=<format>]
+[**--decrypt**] [**--password-file**=<file>] [**--force**] [**--verbose**]
+[**--out**=<file>] [**--mkdir**]
 [**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
 		Description: `**step ca provisioner jwe-key** returns the encrypted
-private jwk for the given key-id.
+private jwk for the given key-id or provisioner name. The argument is first
+looked up as a provisioner name, and then as the key-id of a JWK provisioner.
+
+With **--format pem**, the public key of the JWK provisioner is printed in PEM
+format (SPKI) instead, e.g. to verify the provisioning tokens with OpenSSL based
+tools. Provisioners without a public key, like OIDC provisioners, are not
+supported.
+
+With **--decrypt**, the key is decrypted and the private jwk is printed. As this
+exposes the private key, a confirmation is requested unless **--force** is used.
+The decrypted key is never written to disk, so **--decrypt** cannot be used
+with **--out**.
 
 ## EXAMPLES
 
@@ -26,8 +50,71 @@ Retrieve the encrypted private jwk for the given key-id:
 '''
 $ step ca provisioner jwe-key 1234 --ca-url https://127.0.0.1 --root ./root.crt
 '''
+
+Retrieve the encrypted private jwk of the provisioner with the given name:
+'''
+$ step ca provisioner jwe-key admin
+'''
+
+Retrieve the encrypted private jwk for the given key-id as a JSON object:
+'''
+$ step ca provisioner jwe-key 1234 --format json
+'''
+
+Retrieve the public key of a provisioner in PEM format:
+'''
+$ step ca provisioner jwe-key admin --format pem
+'''
+
+Write the encrypted private jwk of a provisioner to a file, creating its directory:
+'''
+$ step ca provisioner jwe-key admin --out ./secrets/admin.jwe --mkdir
+'''
+
+Retrieve and decrypt the private jwk for the given key-id:
+'''
+$ step ca provisioner jwe-key 1234 --decrypt --password-file ./password.txt
+'''
 `,
 		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: `The output format for printing the encrypted key.
+
+: <format> is a string and must be one of:
+
+    **text**
+    :  Print the JWE compact serialization of the key. (default)
+
+    **json**
+    :  Print a JSON object with the key-id and the encrypted key.
+
+    **pem**
+    :  Print the public key of the provisioner in PEM format.`,
+			},
+			cli.BoolFlag{
+				Name:  "decrypt",
+				Usage: `Decrypt the key and print the private jwk.`,
+			},
+			flags.PasswordFile,
+			cli.BoolFlag{
+				Name:  "force",
+				Usage: `Print the decrypted key without asking for confirmation.`,
+			},
+			cli.StringFlag{
+				Name: "out",
+				Usage: `Write the key to <file> instead of the standard output. The file is
+created with 0600 permissions.`,
+			},
+			cli.BoolFlag{
+				Name:  "mkdir",
+				Usage: `Create the parent directories of the **--out** file if they do not exist.`,
+			},
+			cli.BoolFlag{
+				Name:  "verbose",
+				Usage: `Print if the argument was resolved as a provisioner name or a key-id.`,
+			},
 			flags.CaURL,
 			flags.Root,
 			flags.Context,
@@ -40,18 +127,167 @@ func getEncryptedKeyAction(ctx *cli.Context) error {
 		return err
 	}
 
-	kid := ctx.Args().Get(0)
+	format := ctx.String("format")
+	if format != "text" && format != "json" && format != "pem" {
+		return errs.InvalidFlagValue(ctx, "format", format, "text, json, pem")
+	}
+	if format == "pem" && ctx.Bool("decrypt") {
+		return errs.IncompatibleFlagValue(ctx, "decrypt", "format", format)
+	}
+
+	out := ctx.String("out")
+	switch {
+	case out != "" && ctx.Bool("decrypt"):
+		return errs.IncompatibleFlagWithFlag(ctx, "out", "decrypt")
+	case out == "" && ctx.Bool("mkdir"):
+		return errs.RequiredWithFlag(ctx, "mkdir", "out")
+	case out != "":
+		// Check the output before requesting the key.
+		if err := validateOutputFile(out, ctx.Bool("mkdir")); err != nil {
+			return err
+		}
+	}
+
+	arg := ctx.Args().Get(0)
 	root := ctx.String("root")
 	caURL, err := flags.ParseCaURL(ctx)
 	if err != nil {
 		return err
 	}
 
-	key, err := pki.GetProvisionerKey(caURL, root, kid)
+	provisioners, err := pki.GetProvisioners(caURL, root)
 	if err != nil {
-		return errors.Wrap(err, "error getting the provisioning key")
+		return errors.Wrap(err, "error getting the provisioners")
+	}
+	p, byName, ok := cautils.LookupProvisioner(provisioners, arg)
+	if !ok {
+		return errors.Errorf("provisioner with name or key-id %s not found", arg)
+	}
+	var kid, key string
+	if format == "pem" {
+		if kid, key, err = publicKeyPEM(p); err != nil {
+			return err
+		}
+	} else if kid, key, ok = p.GetEncryptedKey(); !ok {
+		return errors.Errorf("provisioner %s does not have an encrypted key", p.GetName())
+	}
+	if ctx.Bool("verbose") {
+		if byName {
+			fmt.Fprintf(os.Stderr, "Resolved %s as the name of the provisioner with key-id %s.\n", arg, kid)
+		} else {
+			fmt.Fprintf(os.Stderr, "Resolved %s as the key-id of the provisioner %s.\n", arg, p.GetName())
+		}
+	}
+
+	if ctx.Bool("decrypt") {
+		return printDecryptedKey(ctx, kid, key, format)
 	}
 
-	fmt.Println(key)
+	data := key
+	if format == "json" {
+		b, err := json.MarshalIndent(map[string]string{
+			"kid": kid,
+			"key": key,
+		}, "", "   ")
+		if err != nil {
+			return errors.Wrap(err, "error marshaling provisioning key")
+		}
+		data = string(b)
+	}
+
+	if out == "" {
+		fmt.Println(data)
+		return nil
+	}
+	if err := utils.WriteFile(out, []byte(data+"\n"), 0600); err != nil {
+		return errs.FileError(err, out)
+	}
+	if err := os.Chmod(out, 0600); err != nil {
+		return errs.FileError(err, out)
+	}
+	if format == "pem" {
+		ui.Printf("Your public key has been saved in %s.\n", out)
+	} else {
+		ui.Printf("Your encrypted key has been saved in %s.\n", out)
+	}
+	return nil
+}
+
+// publicKeyPEM returns the key-id and the PEM encoded public key of a JWK
+// provisioner.
+func publicKeyPEM(p provisioner.Interface) (string, string, error) {
+	jwk, ok := p.(*provisioner.JWK)
+	if !ok || jwk.Key == nil {
+		return "", "", errors.Errorf("provisioner %s of type %s does not have a public key", p.GetName(), p.GetType())
+	}
+	block, err := pemutil.Serialize(jwk.Key.Key)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "error serializing the public key of provisioner %s", p.GetName())
+	}
+	return jwk.Key.KeyID, strings.TrimSpace(string(pem.EncodeToMemory(block))), nil
+}
+
+// validateOutputFile checks that the given file can be written, its parent
+// directory is created if mkdir is true.
+func validateOutputFile(filename string, mkdir bool) error {
+	dir := filepath.Dir(filename)
+	if _, err := os.Stat(dir); err != nil {
+		if !os.IsNotExist(err) || !mkdir {
+			return errs.FileError(err, dir)
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return errs.FileError(err, dir)
+		}
+	}
+	if st, err := os.Stat(filename); err == nil && st.IsDir() {
+		return errors.Errorf("error writing %s: is a directory", filename)
+	}
+
+	f, err := os.CreateTemp(dir, ".jwe-key-*")
+	if err != nil {
+		return errors.Wrapf(err, "error writing %s", filename)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// printDecryptedKey decrypts the given JWE encrypted key and prints the
+// private jwk.
+func printDecryptedKey(ctx *cli.Context, kid, key, format string) error {
+	if !ctx.Bool("force") {
+		ok, err := ui.PromptYesNo("The private key will be printed in plaintext. Do you want to continue? [y/n]")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("operation canceled")
+		}
+	}
+
+	var opts []jose.Option
+	if passwordFile := ctx.String("password-file"); passwordFile != "" {
+		opts = append(opts, jose.WithPasswordFile(passwordFile))
+	}
+	b, err := jose.Decrypt("Please enter the password to decrypt the provisioner key", []byte(key), opts...)
+	if err != nil {
+		return err
+	}
+	var jwk jose.JSONWebKey
+	if err := json.Unmarshal(b, &jwk); err != nil {
+		return errors.Wrap(err, "error parsing the provisioning key")
+	}
+
+	var v interface{} = &jwk
+	if format == "json" {
+		v = map[string]interface{}{
+			"kid": kid,
+			"key": &jwk,
+		}
+	}
+	b, err = json.MarshalIndent(v, "", "   ")
+	if err != nil {
+		return errors.Wrap(err, "error marshaling provisioning key")
+	}
+	fmt.Println(string(b))
 	return nil
 }
@@ -0,0 +1,49 @@
+package provisioner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"reflect"
+	"testing"
+
+	"github.com/smallstep/certificates/authority/provisioner"
+	"go.step.sm/crypto/jose"
+)
+
+func TestPublicKeyPEM(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &provisioner.JWK{
+		Name: "admin",
+		Type: "JWK",
+		Key:  &jose.JSONWebKey{Key: key.Public(), KeyID: "the-kid"},
+	}
+
+	kid, s, err := publicKeyPEM(p)
+	if err != nil {
+		t.Fatalf("publicKeyPEM() error = %v", err)
+	}
+	if kid != "the-kid" {
+		t.Errorf("publicKeyPEM() kid = %s, want the-kid", kid)
+	}
+	block, rest := pem.Decode([]byte(s))
+	if block == nil || len(rest) != 0 || block.Type != "PUBLIC KEY" {
+		t.Fatalf("publicKeyPEM() = %s, want a PUBLIC KEY block", s)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("x509.ParsePKIXPublicKey() error = %v", err)
+	}
+	if !reflect.DeepEqual(pub, key.Public()) {
+		t.Errorf("publicKeyPEM() = %v, want %v", pub, key.Public())
+	}
+
+	if _, _, err := publicKeyPEM(&provisioner.OIDC{Name: "google", Type: "OIDC"}); err == nil {
+		t.Error("publicKeyPEM() with an OIDC provisioner error = nil, want error")
+	}
+}
@@ -1,14 +1,56 @@
 package provisioner
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
 
 	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/config"
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/certificates/ca"
 	"github.com/smallstep/certificates/pki"
 	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/jose"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
+	"go.step.sm/cli-utils/ui"
+	"golang.org/x/term"
+)
+
+var (
+	typeFilterFlag = cli.StringSliceFlag{
+		Name: "type",
+		Usage: `Only include the provisioners of the given <type>. Type is a case-insensitive
+string, like JWK, OIDC or ACME. Use the flag multiple times to include multiple types.`,
+	}
+	nameFilterFlag = cli.StringFlag{
+		Name: "filter",
+		Usage: `Only include the provisioners with a name containing the given <substring>.
+The match is case-insensitive. If used with **--type**, the provisioners must
+match both.`,
+	}
+	disabledOnlyFlag = cli.BoolFlag{
+		Name: "disabled-only",
+		Usage: `Only include the disabled provisioners, the ones with the renewal of
+certificates disabled. The **disableRenewal** claim of the provisioner is used,
+or if it is not set, the global one in the **--ca-config** file, if it exists.
+The CA does not expose if a provisioner can sign x509 certificates, so it is not
+considered. Can be combined with **--type** and **--filter**.`,
+	}
+	enabledOnlyFlag = cli.BoolFlag{
+		Name: "enabled-only",
+		Usage: `Only include the provisioners that are not disabled, see **--disabled-only**.
+Can be combined with **--type** and **--filter**.`,
+	}
 )
 
 func listCommand() cli.Command {
@@ -16,9 +58,120 @@ func listCommand() cli.Command {
 		Name:   "list",
 		Action: cli.ActionFunc(listAction),
 		Usage:  "list provisioners configured in the CA",
-		UsageText: `**step ca provisioner list**
-[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		UsageText: `**step ca provisioner list** [**--format**=<format>] [**--long**] [**--columns**=<columns>] [**--no-color**]
+[**--output-template**=<template>] [**--type**=<type>...] [**--filter**=<substring>]
+[**--disabled-only**] [**--enabled-only**] [**--sort**=<order>] [**--show-key-thumbprint**] [**--quiet**]
+[**--ca-config**=<file>] [**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
 		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Value: "json",
+				Usage: `The output format for printing the provisioners.
+
+: <format> is a string and must be one of:
+
+    **json**
+    :  Print output in JSON format. (default)
+
+    **text**
+    :  Print output in unstructured text suitable for a human to read.
+
+    **jsonl**
+    :  Print one JSON object per line, as the provisioners are received from the
+    CA, without waiting for the whole list. It can only be used with the default
+    **--sort** order.
+
+    **csv**
+    :  Print a header row and one row per provisioner with the columns name, type,
+    ssh, renewal and key-thumbprint, suitable for a spreadsheet.`,
+			},
+			cli.BoolFlag{
+				Name: "long",
+				Usage: `Include the cloud provisioner options **disableCustomSANs** and
+**disableTrustOnFirstUse** in the text output. Requires **--format text**.`,
+			},
+			cli.StringFlag{
+				Name: "columns",
+				Usage: `The comma separated list of <columns> printed in the text output, in the given
+order. Requires **--format text** and cannot be used with **--long**.
+
+: <columns> are one or more of:
+
+    **position**
+    :  The position of the provisioner in the CA.
+
+    **name**
+    :  The name of the provisioner.
+
+    **type**
+    :  The type of the provisioner, dim if the provisioner is disabled, see
+    **--disabled-only**.
+
+    **id**
+    :  The id of the provisioner.
+
+    **ssh**
+    :  If the provisioner can sign ssh certificates, or "default" if it uses the
+    global configuration of the CA.
+
+    **renewal**
+    :  If the certificates can be renewed, "after expiry" if expired certificates
+    can also be renewed, or "default" if it uses the global configuration of the CA.
+
+    **custom-sans**
+    :  The **disableCustomSANs** option of the cloud provisioners.
+
+    **tofu**
+    :  The **disableTrustOnFirstUse** option of the cloud provisioners.
+
+    **thumbprint**
+    :  The thumbprint (RFC7638) of the public key of the JWK provisioners.`,
+			},
+			cli.BoolFlag{
+				Name: "show-key-thumbprint",
+				Usage: `Include the SHA-256 thumbprint (RFC7638) of the public key of the JWK
+provisioners in the text output, as a base64-urlencoded string. Other
+provisioner types have no key and print "-". Requires **--format text**.`,
+			},
+			cli.BoolFlag{
+				Name: "no-color",
+				Usage: `Do not use colors in the text output. Colors are also disabled if the
+standard output is not a terminal or the **NO_COLOR** environment variable is set.`,
+			},
+			cli.StringFlag{
+				Name: "output-template",
+				Usage: `Print each provisioner using the given Go text/template <template>, for
+example '{{.Name}} {{.Type}}'. A new line is added after each provisioner.
+Cannot be used with **--format**.`,
+			},
+			cli.StringFlag{
+				Name:  "sort",
+				Value: "position",
+				Usage: `The <order> used to print the provisioners.
+
+: <order> is a string and must be one of:
+
+    **position**
+    :  The order in which the provisioners are stored in the CA configuration. (default)
+
+    **name**
+    :  Sort by name.
+
+    **type**
+    :  Sort by type, keeping the stored order within each type.`,
+			},
+			cli.BoolFlag{
+				Name: "quiet",
+				Usage: `Do not print the provisioners. The command exits with status code 0 if the CA
+returns at least one provisioner matching the filters, and 1 otherwise. Errors
+are still printed to the standard error. Cannot be used with **--format**,
+**--long** or **--output-template**.`,
+			},
+			typeFilterFlag,
+			nameFilterFlag,
+			disabledOnlyFlag,
+			enabledOnlyFlag,
+			flags.CaConfig,
 			flags.CaURL,
 			flags.Root,
 			flags.Context,
@@ -26,11 +179,78 @@ func listCommand() cli.Command {
 		Description: `**step ca provisioner list** lists the provisioners configured
 in the CA.
 
+The provisioners are printed in the order they are stored in the CA, the order
+used by the CA when more than one provisioner can handle a request, e.g. with
+multiple ACME provisioners. The text output includes the position of each
+provisioner in that order in the **#** column, even when the list is filtered or
+sorted with **--sort**. The CA does not expose when a provisioner was created.
+
 ## EXAMPLES
 
 Prints a JSON list with active provisioners:
 '''
 $ step ca provisioner list
+'''
+
+Prints a table with the position, name, type, id and SSH status of the active provisioners:
+'''
+$ step ca provisioner list --format text
+'''
+
+Prints a table including the custom SANs and trust on first use settings of
+the cloud provisioners:
+'''
+$ step ca provisioner list --format text --long
+'''
+
+Prints a table with the name, type, ssh and renewal settings of the provisioners:
+'''
+$ step ca provisioner list --format text --columns name,type,ssh,renewal
+'''
+
+Prints a table including the key thumbprint of the JWK provisioners:
+'''
+$ step ca provisioner list --format text --show-key-thumbprint
+'''
+
+Prints the name and type of each provisioner using a template:
+'''
+$ step ca provisioner list --output-template '{{.Name}} {{.Type}}'
+'''
+
+Prints the ACME and SCEP provisioners:
+'''
+$ step ca provisioner list --type acme --type scep
+'''
+
+Prints the JWK provisioners with "ci" in the name:
+'''
+$ step ca provisioner list --type jwk --filter ci
+'''
+
+Prints the disabled provisioners, e.g. to find the ones that can be removed:
+'''
+$ step ca provisioner list --format text --disabled-only
+'''
+
+Checks that the CA is reachable and has provisioners, e.g. in a readiness probe:
+'''
+$ step ca provisioner list --quiet
+'''
+
+Prints a table sorted by type:
+'''
+$ step ca provisioner list --format text --sort type
+'''
+
+Prints the provisioners in CSV format for a spreadsheet:
+'''
+$ step ca provisioner list --format csv > provisioners.csv
+'''
+
+Streams the provisioners, one JSON object per line:
+'''
+$ step ca provisioner list --format jsonl | jq -r .name
 '''`,
 	}
 }
@@ -40,15 +260,334 @@ func listAction(ctx *cli.Context) error {
 		return err
 	}
 
+	format := ctx.String("format")
+	if format != "json" && format != "text" && format != "jsonl" && format != "csv" {
+		return errs.InvalidFlagValue(ctx, "format", format, "json, text, jsonl, csv")
+	}
+	if ctx.Bool("long") && format != "text" {
+		return errs.IncompatibleFlagValue(ctx, "long", "format", format)
+	}
+	columns := defaultListColumns
+	if ctx.Bool("long") {
+		columns = longListColumns
+	}
+	if value := ctx.String("columns"); value != "" {
+		if format != "text" {
+			return errs.IncompatibleFlagValue(ctx, "columns", "format", format)
+		}
+		if ctx.Bool("long") {
+			return errs.IncompatibleFlagWithFlag(ctx, "columns", "long")
+		}
+		var err error
+		if columns, err = parseListColumns(ctx, value); err != nil {
+			return err
+		}
+	}
+	if ctx.Bool("show-key-thumbprint") {
+		if format != "text" {
+			return errs.IncompatibleFlagValue(ctx, "show-key-thumbprint", "format", format)
+		}
+		columns = withColumn(columns, "thumbprint")
+	}
+
+	if ctx.Bool("quiet") {
+		for _, name := range []string{"format", "long", "columns", "output-template", "show-key-thumbprint"} {
+			if ctx.IsSet(name) {
+				return errs.IncompatibleFlagWithFlag(ctx, "quiet", name)
+			}
+		}
+	}
+
+	// Parse the template before any request or output.
+	var tmpl *template.Template
+	if text := ctx.String("output-template"); text != "" {
+		if ctx.IsSet("format") {
+			return errs.IncompatibleFlagWithFlag(ctx, "output-template", "format")
+		}
+		var err error
+		if tmpl, err = template.New("output-template").Parse(text); err != nil {
+			return errs.InvalidFlagValueMsg(ctx, "output-template", text, err.Error())
+		}
+	}
+
+	sortBy := ctx.String("sort")
+	if sortBy != "position" && sortBy != "name" && sortBy != "type" {
+		return errs.InvalidFlagValue(ctx, "sort", sortBy, "position, name, type")
+	}
+	if format == "jsonl" {
+		if sortBy != "position" {
+			return errs.IncompatibleFlagValue(ctx, "sort", "format", format)
+		}
+		return streamProvisioners(ctx)
+	}
+
+	provisioners, all, global, err := getFilteredProvisioners(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("quiet") {
+		if len(provisioners) == 0 {
+			return errors.New("no provisioners found")
+		}
+		return nil
+	}
+	if len(ctx.StringSlice("type")) > 0 || ctx.String("filter") != "" || ctx.Bool("disabled-only") || ctx.Bool("enabled-only") {
+		ui.Printf("showing %d of %d provisioners\n", len(provisioners), len(all))
+	}
+	positions := provisionerPositions(all)
+	provisioners = sortProvisioners(provisioners, sortBy, positions)
+
+	switch {
+	case tmpl != nil:
+		return printProvisionersTemplate(provisioners, tmpl)
+	case format == "text":
+		return printProvisionersText(provisioners, positions, global, columns, useColor(ctx))
+	case format == "csv":
+		return writeProvisionersCSV(os.Stdout, provisioners, positions, global)
+	default:
+		return printProvisionersJSON(provisioners)
+	}
+}
+
+// getFilteredProvisioners returns the provisioners in the CA matching the
+// --type and --filter flags, all the provisioners in the CA, and the global
+// claims in the --ca-config file, if it exists.
+func getFilteredProvisioners(ctx *cli.Context) (provisioner.List, provisioner.List, *provisioner.Claims, error) {
+	types := ctx.StringSlice("type")
+	if err := validateProvisionerTypes(ctx, types); err != nil {
+		return nil, nil, nil, err
+	}
+	if ctx.Bool("disabled-only") && ctx.Bool("enabled-only") {
+		return nil, nil, nil, errs.MutuallyExclusiveFlags(ctx, "disabled-only", "enabled-only")
+	}
+
+	root := ctx.String("root")
+	caURL, err := flags.ParseCaURL(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	global, err := readGlobalClaims(ctx.String("ca-config"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	all, err := pki.GetProvisioners(caURL, root)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "error getting the provisioners")
+	}
+	provisioners := all
+	if len(types) > 0 {
+		provisioners = filterProvisionersByType(provisioners, types)
+	}
+	if filter := ctx.String("filter"); filter != "" {
+		provisioners = filterProvisionersByName(provisioners, filter)
+	}
+	if ctx.Bool("disabled-only") || ctx.Bool("enabled-only") {
+		provisioners = filterProvisionersByState(provisioners, global, ctx.Bool("disabled-only"))
+	}
+	return provisioners, all, global, nil
+}
+
+// streamProvisioners prints the provisioners in the CA matching the --type
+// and --filter flags as JSON lines, page by page.
+func streamProvisioners(ctx *cli.Context) error {
+	types := ctx.StringSlice("type")
+	if err := validateProvisionerTypes(ctx, types); err != nil {
+		return err
+	}
+	if ctx.Bool("disabled-only") && ctx.Bool("enabled-only") {
+		return errs.MutuallyExclusiveFlags(ctx, "disabled-only", "enabled-only")
+	}
 	root := ctx.String("root")
+	if root == "" {
+		root = pki.GetRootCAPath()
+	}
 	caURL, err := flags.ParseCaURL(ctx)
 	if err != nil {
 		return err
 	}
+	client, err := ca.NewClient(caURL, ca.WithRootFile(root))
+	if err != nil {
+		return err
+	}
+
+	filter := ctx.String("filter")
+	byState, disabled := ctx.Bool("disabled-only") || ctx.Bool("enabled-only"), ctx.Bool("disabled-only")
+	var global *provisioner.Claims
+	if byState {
+		if global, err = readGlobalClaims(ctx.String("ca-config")); err != nil {
+			return err
+		}
+	}
+	return writeProvisionersJSONL(os.Stdout, func(cursor string) (provisioner.List, string, error) {
+		resp, err := client.Provisioners(ca.WithProvisionerCursor(cursor), ca.WithProvisionerLimit(100))
+		if err != nil {
+			return nil, "", err
+		}
+		provisioners := resp.Provisioners
+		if len(types) > 0 {
+			provisioners = filterProvisionersByType(provisioners, types)
+		}
+		if filter != "" {
+			provisioners = filterProvisionersByName(provisioners, filter)
+		}
+		if byState {
+			provisioners = filterProvisionersByState(provisioners, global, disabled)
+		}
+		return provisioners, resp.NextCursor, nil
+	})
+}
+
+// writeProvisionersJSONL writes one JSON object per provisioner and line,
+// requesting the next page of provisioners once the previous one is written.
+func writeProvisionersJSONL(w io.Writer, nextPage func(cursor string) (provisioner.List, string, error)) error {
+	enc := json.NewEncoder(w)
+	var cursor string
+	for {
+		provisioners, next, err := nextPage(cursor)
+		if err != nil {
+			return errors.Wrap(err, "error getting the provisioners")
+		}
+		for _, p := range provisioners {
+			if err := enc.Encode(p); err != nil {
+				return errors.Wrapf(err, "error writing provisioner %s", p.GetName())
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// provisionerPositions returns the 1-based position of each provisioner in the
+// list returned by the CA.
+func provisionerPositions(provisioners provisioner.List) map[provisioner.Interface]int {
+	positions := make(map[provisioner.Interface]int, len(provisioners))
+	for i, p := range provisioners {
+		positions[p] = i + 1
+	}
+	return positions
+}
+
+// sortProvisioners returns a copy of the list sorted by name or type. The list
+// is returned as is when sorting by position, the order of the CA.
+func sortProvisioners(provisioners provisioner.List, by string, positions map[provisioner.Interface]int) provisioner.List {
+	if by != "name" && by != "type" {
+		return provisioners
+	}
+	list := make(provisioner.List, len(provisioners))
+	copy(list, provisioners)
+	sort.SliceStable(list, func(i, j int) bool {
+		if by == "name" {
+			a, b := strings.ToLower(list[i].GetName()), strings.ToLower(list[j].GetName())
+			if a != b {
+				return a < b
+			}
+		} else if a, b := list[i].GetType(), list[j].GetType(); a != b {
+			return a.String() < b.String()
+		}
+		return positions[list[i]] < positions[list[j]]
+	})
+	return list
+}
+
+// validateProvisionerTypes checks that all the given types are valid
+// provisioner types.
+func validateProvisionerTypes(ctx *cli.Context, types []string) error {
+	var options []string
+	for t := provisioner.TypeJWK; t <= provisioner.TypeNebula; t++ {
+		options = append(options, t.String())
+	}
+	for _, typ := range types {
+		var ok bool
+		for _, o := range options {
+			if strings.EqualFold(typ, o) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return errs.InvalidFlagValue(ctx, "type", typ, strings.Join(options, ", "))
+		}
+	}
+	return nil
+}
+
+// filterProvisionersByType returns the provisioners matching any of the given
+// types.
+func filterProvisionersByType(provisioners provisioner.List, types []string) provisioner.List {
+	list := provisioner.List{}
+	for _, p := range provisioners {
+		for _, typ := range types {
+			if isProvisionerType(p, typ) {
+				list = append(list, p)
+				break
+			}
+		}
+	}
+	return list
+}
+
+// filterProvisionersByName returns the provisioners with a name containing the
+// given substring, ignoring the case.
+func filterProvisionersByName(provisioners provisioner.List, substr string) provisioner.List {
+	substr = strings.ToLower(substr)
+	list := provisioner.List{}
+	for _, p := range provisioners {
+		if strings.Contains(strings.ToLower(p.GetName()), substr) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
 
-	provisioners, err := pki.GetProvisioners(caURL, root)
+// filterProvisionersByState returns the disabled provisioners if disabled is
+// true, or the rest of them otherwise. The global claims can be nil.
+func filterProvisionersByState(provisioners provisioner.List, global *provisioner.Claims, disabled bool) provisioner.List {
+	list := provisioner.List{}
+	for _, p := range provisioners {
+		if isProvisionerDisabled(p, global) == disabled {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// isProvisionerDisabled returns true if the given provisioner cannot renew
+// certificates. The disableRenewal claim of the provisioner is used, or if it
+// is not set, the one in the global claims, or the default of the CA.
+func isProvisionerDisabled(p provisioner.Interface, global *provisioner.Claims) bool {
+	if claims := provisionerClaims(p); claims != nil && claims.DisableRenewal != nil {
+		return *claims.DisableRenewal
+	}
+	if global != nil && global.DisableRenewal != nil {
+		return *global.DisableRenewal
+	}
+	return *config.GlobalProvisionerClaims.DisableRenewal
+}
+
+// readGlobalClaims returns the global provisioner claims in the given ca.json
+// file, or nil if the file does not exist.
+func readGlobalClaims(caConfig string) (*provisioner.Claims, error) {
+	if !fileExists(caConfig) {
+		return nil, nil
+	}
+	c, err := config.LoadConfiguration(caConfig)
 	if err != nil {
-		return errors.Wrap(err, "error getting the provisioners")
+		return nil, errors.Wrapf(err, "error loading %s", caConfig)
+	}
+	if c.AuthorityConfig == nil {
+		return nil, nil
+	}
+	return c.AuthorityConfig.Claims, nil
+}
+
+func printProvisionersJSON(provisioners provisioner.List) error {
+	// Always print a valid JSON array, even if there are no provisioners.
+	if provisioners == nil {
+		provisioners = provisioner.List{}
 	}
 
 	b, err := json.MarshalIndent(provisioners, "", "   ")
@@ -59,3 +598,306 @@ func listAction(ctx *cli.Context) error {
 	fmt.Println(string(b))
 	return nil
 }
+
+// printProvisionersTemplate prints each provisioner using the given template.
+// Nothing is printed if the template fails with any of the provisioners.
+func printProvisionersTemplate(provisioners provisioner.List, tmpl *template.Template) error {
+	var buf bytes.Buffer
+	for _, p := range provisioners {
+		if err := tmpl.Execute(&buf, p); err != nil {
+			return errors.Wrapf(err, "error executing template for provisioner %s", p.GetName())
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// listColumn is a column in the text output of the list command.
+type listColumn struct {
+	header string
+	// colored columns always include the escape codes when colors are
+	// enabled, so they are aligned by the tabwriter.
+	colored bool
+	value   func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string
+}
+
+// listColumns are the columns that can be used with --columns.
+var listColumns = map[string]listColumn{
+	"position": {"#", false, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		return strconv.Itoa(position)
+	}},
+	"name": {"NAME", false, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		return p.GetName()
+	}},
+	"type": {"TYPE", true, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		return colorizeType(color, p, global)
+	}},
+	"id": {"ID", false, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		return p.GetID()
+	}},
+	"ssh": {"SSH", true, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		return colorizeSSH(color, p)
+	}},
+	"renewal": {"RENEWAL", true, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		return colorizeRenewal(color, p)
+	}},
+	"custom-sans": {"DISABLE CUSTOM SANS", false, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		if disableCustomSANs, _, ok := cloudProvisionerOptions(p); ok {
+			return fmt.Sprint(disableCustomSANs)
+		}
+		return "-"
+	}},
+	"tofu": {"DISABLE TOFU", false, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		if _, disableTOFU, ok := cloudProvisionerOptions(p); ok {
+			if disableTOFU {
+				return "true (!)"
+			}
+			return "false"
+		}
+		return "-"
+	}},
+	"thumbprint": {"KEY THUMBPRINT", false, func(p provisioner.Interface, position int, global *provisioner.Claims, color bool) string {
+		if thumbprint := keyThumbprint(p); thumbprint != "" {
+			return thumbprint
+		}
+		return "-"
+	}},
+}
+
+// Default columns of the text output, with and without --long.
+var (
+	defaultListColumns = []string{"position", "name", "type", "id", "ssh"}
+	longListColumns    = []string{"position", "name", "type", "id", "ssh", "custom-sans", "tofu"}
+)
+
+// parseListColumns parses the comma separated list of columns in --columns.
+func parseListColumns(ctx *cli.Context, value string) ([]string, error) {
+	var names []string
+	for name := range listColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var columns []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := listColumns[name]; !ok {
+			return nil, errs.InvalidFlagValue(ctx, "columns", name, strings.Join(names, ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// csvListColumns are the header and the column of each field in the CSV
+// output. They are fixed so the output is stable.
+var csvListColumns = []struct {
+	header string
+	column string
+}{
+	{"name", "name"},
+	{"type", "type"},
+	{"ssh", "ssh"},
+	{"renewal", "renewal"},
+	{"key-thumbprint", "thumbprint"},
+}
+
+// writeProvisionersCSV writes a header row and one row per provisioner in CSV
+// format.
+func writeProvisionersCSV(w io.Writer, provisioners provisioner.List, positions map[provisioner.Interface]int, global *provisioner.Claims) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(csvListColumns))
+	for i, c := range csvListColumns {
+		record[i] = c.header
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for _, p := range provisioners {
+		for i, c := range csvListColumns {
+			record[i] = listColumns[c.column].value(p, positions[p], global, false)
+		}
+		if err := cw.Write(record); err != nil {
+			return errors.Wrapf(err, "error writing provisioner %s", p.GetName())
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// withColumn returns the columns with the given one appended, unless it is
+// already included.
+func withColumn(columns []string, column string) []string {
+	for _, name := range columns {
+		if name == column {
+			return columns
+		}
+	}
+	return append(append([]string{}, columns...), column)
+}
+
+// keyThumbprint returns the thumbprint of the public key of a JWK
+// provisioner, or an empty string for other provisioner types.
+func keyThumbprint(p provisioner.Interface) string {
+	jwk, ok := p.(*provisioner.JWK)
+	if !ok || jwk.Key == nil {
+		return ""
+	}
+	thumbprint, err := jose.Thumbprint(jwk.Key)
+	if err != nil {
+		return ""
+	}
+	return thumbprint
+}
+
+func printProvisionersText(provisioners provisioner.List, positions map[provisioner.Interface]int, global *provisioner.Claims, columns []string, color bool) error {
+	w := new(tabwriter.Writer)
+	// Format in tab-separated columns with a tab stop of 8.
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+
+	row := make([]string, len(columns))
+	for i, name := range columns {
+		c := listColumns[name]
+		row[i] = c.header
+		if c.colored {
+			row[i] = colorize(color, colorNone, c.header)
+		}
+	}
+	fmt.Fprintln(w, strings.Join(row, "\t"))
+
+	var tofu bool
+	var tofuDisabled []string
+	for _, name := range columns {
+		tofu = tofu || name == "tofu"
+	}
+	for _, p := range provisioners {
+		for i, name := range columns {
+			row[i] = listColumns[name].value(p, positions[p], global, color)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+		if _, disableTOFU, ok := cloudProvisionerOptions(p); tofu && ok && disableTOFU {
+			tofuDisabled = append(tofuDisabled, p.GetName())
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(tofuDisabled) > 0 {
+		fmt.Fprintf(os.Stderr, "\nwarning: trust on first use is disabled in: %s\n", strings.Join(tofuDisabled, ", "))
+	}
+	return nil
+}
+
+// cloudProvisionerOptions returns the disableCustomSANs and
+// disableTrustOnFirstUse options of the AWS, GCP and Azure provisioners. The
+// last value is false for any other provisioner type.
+func cloudProvisionerOptions(p provisioner.Interface) (disableCustomSANs, disableTrustOnFirstUse, ok bool) {
+	switch p := p.(type) {
+	case *provisioner.AWS:
+		return p.DisableCustomSANs, p.DisableTrustOnFirstUse, true
+	case *provisioner.GCP:
+		return p.DisableCustomSANs, p.DisableTrustOnFirstUse, true
+	case *provisioner.Azure:
+		return p.DisableCustomSANs, p.DisableTrustOnFirstUse, true
+	default:
+		return false, false, false
+	}
+}
+
+// ANSI colors used in the text output. All of them have the same length, so
+// the columns are still aligned by the tabwriter when colors are enabled.
+const (
+	colorNone  = "00"
+	colorDim   = "02"
+	colorGreen = "32"
+)
+
+// useColor returns true if the text output can be colorized: the standard
+// output is a terminal, and neither --no-color nor NO_COLOR are set.
+func useColor(ctx *cli.Context) bool {
+	if ctx.Bool("no-color") || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// colorize wraps s with the escape codes of the given color if enabled is
+// true.
+func colorize(enabled bool, color, s string) string {
+	if !enabled {
+		return s
+	}
+	return "\033[" + color + "m" + s + "\033[0m"
+}
+
+// colorizeType returns the type of the provisioner. It is dim if the
+// provisioner is disabled, see isProvisionerDisabled, and green otherwise.
+func colorizeType(enabled bool, p provisioner.Interface, global *provisioner.Claims) string {
+	if isProvisionerDisabled(p, global) {
+		return colorize(enabled, colorDim, p.GetType().String())
+	}
+	return colorize(enabled, colorGreen, p.GetType().String())
+}
+
+// colorizeSSH returns the status of the SSH CA in the provisioner claims:
+// "enabled" in green, "disabled" in dim, or "default" if the provisioner uses
+// the global configuration of the CA.
+func colorizeSSH(enabled bool, p provisioner.Interface) string {
+	claims := provisionerClaims(p)
+	switch {
+	case claims == nil || claims.EnableSSHCA == nil:
+		return colorize(enabled, colorNone, "default")
+	case *claims.EnableSSHCA:
+		return colorize(enabled, colorGreen, "enabled")
+	default:
+		return colorize(enabled, colorDim, "disabled")
+	}
+}
+
+// colorizeRenewal returns the renewal settings in the provisioner claims:
+// "disabled" in dim, "enabled" or "after expiry" in green, or "default" if the
+// provisioner uses the global configuration of the CA.
+func colorizeRenewal(enabled bool, p provisioner.Interface) string {
+	claims := provisionerClaims(p)
+	switch {
+	case claims == nil || (claims.DisableRenewal == nil && claims.AllowRenewalAfterExpiry == nil):
+		return colorize(enabled, colorNone, "default")
+	case claims.DisableRenewal != nil && *claims.DisableRenewal:
+		return colorize(enabled, colorDim, "disabled")
+	case claims.AllowRenewalAfterExpiry != nil && *claims.AllowRenewalAfterExpiry:
+		return colorize(enabled, colorGreen, "after expiry")
+	default:
+		return colorize(enabled, colorGreen, "enabled")
+	}
+}
+
+// provisionerClaims returns the claims of the given provisioner.
+func provisionerClaims(p provisioner.Interface) *provisioner.Claims {
+	switch p := p.(type) {
+	case *provisioner.JWK:
+		return p.Claims
+	case *provisioner.OIDC:
+		return p.Claims
+	case *provisioner.GCP:
+		return p.Claims
+	case *provisioner.AWS:
+		return p.Claims
+	case *provisioner.Azure:
+		return p.Claims
+	case *provisioner.ACME:
+		return p.Claims
+	case *provisioner.X5C:
+		return p.Claims
+	case *provisioner.K8sSA:
+		return p.Claims
+	case *provisioner.SSHPOP:
+		return p.Claims
+	case *provisioner.SCEP:
+		return p.Claims
+	case *provisioner.Nebula:
+		return p.Claims
+	default:
+		return nil
+	}
+}
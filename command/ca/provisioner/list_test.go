@@ -0,0 +1,252 @@
+package provisioner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/cli/jose"
+	"github.com/urfave/cli"
+)
+
+func TestParseListColumns(t *testing.T) {
+	ctx := cli.NewContext(&cli.App{}, flag.NewFlagSet("test", 0), nil)
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"name,type,ssh,renewal", []string{"name", "type", "ssh", "renewal"}, false},
+		{" Renewal , NAME", []string{"renewal", "name"}, false},
+		{"name,foo", nil, true},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseListColumns(ctx, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseListColumns(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseListColumns(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestColorizeRenewal(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		claims *provisioner.Claims
+		want   string
+	}{
+		{nil, "default"},
+		{&provisioner.Claims{}, "default"},
+		{&provisioner.Claims{DisableRenewal: &yes}, "disabled"},
+		{&provisioner.Claims{DisableRenewal: &yes, AllowRenewalAfterExpiry: &yes}, "disabled"},
+		{&provisioner.Claims{DisableRenewal: &no}, "enabled"},
+		{&provisioner.Claims{AllowRenewalAfterExpiry: &yes}, "after expiry"},
+	}
+	for _, tt := range tests {
+		p := &provisioner.JWK{Name: "jwk", Type: "JWK", Claims: tt.claims}
+		if got := colorizeRenewal(false, p); got != tt.want {
+			t.Errorf("colorizeRenewal(%+v) = %s, want %s", tt.claims, got, tt.want)
+		}
+	}
+}
+
+func TestColorizeType(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		claims *provisioner.Claims
+		global *provisioner.Claims
+		want   string
+	}{
+		{nil, nil, "\033[32mJWK\033[0m"},
+		{&provisioner.Claims{DisableRenewal: &yes}, nil, "\033[02mJWK\033[0m"},
+		{&provisioner.Claims{DisableRenewal: &yes, EnableSSHCA: &yes}, nil, "\033[02mJWK\033[0m"},
+		{nil, &provisioner.Claims{DisableRenewal: &yes}, "\033[02mJWK\033[0m"},
+		{&provisioner.Claims{DisableRenewal: &no}, &provisioner.Claims{DisableRenewal: &yes}, "\033[32mJWK\033[0m"},
+	}
+	for _, tt := range tests {
+		p := &provisioner.JWK{Name: "jwk", Type: "JWK", Claims: tt.claims}
+		if got := colorizeType(true, p, tt.global); got != tt.want {
+			t.Errorf("colorizeType(%+v, %+v) = %q, want %q", tt.claims, tt.global, got, tt.want)
+		}
+	}
+	if got := colorizeType(true, &provisioner.SSHPOP{Name: "sshpop", Type: "SSHPOP"}, nil); got != "\033[32mSSHPOP\033[0m" {
+		t.Errorf("colorizeType(SSHPOP) = %q, want green", got)
+	}
+}
+
+func TestWriteProvisionersJSONL(t *testing.T) {
+	pages := map[string]provisioner.List{
+		"":  {&provisioner.JWK{Name: "jwk", Type: "JWK"}, &provisioner.ACME{Name: "acme", Type: "ACME"}},
+		"2": {&provisioner.X5C{Name: "x5c", Type: "X5C"}},
+	}
+	nextPage := func(cursor string) (provisioner.List, string, error) {
+		switch cursor {
+		case "":
+			return pages[cursor], "2", nil
+		case "2":
+			return pages[cursor], "3", nil
+		default:
+			return nil, "", errors.New("connection reset")
+		}
+	}
+
+	var buf bytes.Buffer
+	err := writeProvisionersJSONL(&buf, nextPage)
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("writeProvisionersJSONL() error = %v, want connection reset", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("writeProvisionersJSONL() wrote %d lines, want 3", len(lines))
+	}
+	for i, name := range []string{"jwk", "acme", "x5c"} {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[i]), &v); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		if v["name"] != name {
+			t.Errorf("line %d name = %v, want %s", i, v["name"], name)
+		}
+	}
+}
+
+func TestKeyThumbprint(t *testing.T) {
+	jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := jwk.Public()
+	want, err := jose.Thumbprint(&pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		p    provisioner.Interface
+		want string
+	}{
+		{&provisioner.JWK{Name: "jwk", Type: "JWK", Key: &pub}, want},
+		{&provisioner.JWK{Name: "jwk", Type: "JWK"}, ""},
+		{&provisioner.ACME{Name: "acme", Type: "ACME"}, ""},
+	}
+	for _, tt := range tests {
+		if got := keyThumbprint(tt.p); got != tt.want {
+			t.Errorf("keyThumbprint(%s) = %q, want %q", tt.p.GetName(), got, tt.want)
+		}
+	}
+}
+
+func TestWithColumn(t *testing.T) {
+	columns := []string{"name", "type"}
+	if got := withColumn(columns, "thumbprint"); !reflect.DeepEqual(got, []string{"name", "type", "thumbprint"}) {
+		t.Errorf("withColumn() = %v", got)
+	}
+	if got := withColumn(columns, "name"); !reflect.DeepEqual(got, columns) {
+		t.Errorf("withColumn() = %v", got)
+	}
+	// The original columns are not modified.
+	if !reflect.DeepEqual(columns, []string{"name", "type"}) {
+		t.Errorf("columns = %v", columns)
+	}
+}
+
+func TestFilterProvisionersByState(t *testing.T) {
+	yes, no := true, false
+	provisioners := provisioner.List{
+		&provisioner.JWK{Name: "default", Type: "JWK"},
+		&provisioner.JWK{Name: "disabled", Type: "JWK", Claims: &provisioner.Claims{DisableRenewal: &yes, EnableSSHCA: &no}},
+		&provisioner.ACME{Name: "no-renewal", Type: "ACME", Claims: &provisioner.Claims{DisableRenewal: &yes}},
+		&provisioner.OIDC{Name: "ssh", Type: "OIDC", Claims: &provisioner.Claims{DisableRenewal: &yes, EnableSSHCA: &yes}},
+		&provisioner.X5C{Name: "enabled", Type: "X5C", Claims: &provisioner.Claims{DisableRenewal: &no, EnableSSHCA: &no}},
+	}
+	names := func(list provisioner.List) []string {
+		var s []string
+		for _, p := range list {
+			s = append(s, p.GetName())
+		}
+		return s
+	}
+
+	tests := []struct {
+		name         string
+		global       *provisioner.Claims
+		wantDisabled []string
+		wantEnabled  []string
+	}{
+		{"no global claims", nil, []string{"disabled", "no-renewal", "ssh"}, []string{"default", "enabled"}},
+		{"global renewal enabled", &provisioner.Claims{DisableRenewal: &no}, []string{"disabled", "no-renewal", "ssh"}, []string{"default", "enabled"}},
+		{"global renewal disabled", &provisioner.Claims{DisableRenewal: &yes}, []string{"default", "disabled", "no-renewal", "ssh"}, []string{"enabled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(filterProvisionersByState(provisioners, tt.global, true)); !reflect.DeepEqual(got, tt.wantDisabled) {
+				t.Errorf("filterProvisionersByState(true) = %v, want %v", got, tt.wantDisabled)
+			}
+			if got := names(filterProvisionersByState(provisioners, tt.global, false)); !reflect.DeepEqual(got, tt.wantEnabled) {
+				t.Errorf("filterProvisionersByState(false) = %v, want %v", got, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestReadGlobalClaims(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "ca.json")
+	if err := os.WriteFile(filename, []byte(`{"authority":{"claims":{"disableRenewal":true}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := readGlobalClaims(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims == nil || claims.DisableRenewal == nil || !*claims.DisableRenewal {
+		t.Errorf("readGlobalClaims() = %+v, want disableRenewal true", claims)
+	}
+	if claims, err := readGlobalClaims(filepath.Join(dir, "missing.json")); err != nil || claims != nil {
+		t.Errorf("readGlobalClaims() = %v, %v, want nil, nil", claims, err)
+	}
+}
+
+func TestWriteProvisionersCSV(t *testing.T) {
+	yes := true
+	provisioners := provisioner.List{
+		&provisioner.JWK{Name: "ci, staging", Type: "JWK"},
+		&provisioner.ACME{Name: "acme", Type: "ACME", Claims: &provisioner.Claims{DisableRenewal: &yes}},
+	}
+	var buf bytes.Buffer
+	if err := writeProvisionersCSV(&buf, provisioners, provisionerPositions(provisioners), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `name,type,ssh,renewal,key-thumbprint
+"ci, staging",JWK,default,default,-
+acme,ACME,default,disabled,-
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeProvisionersCSV() = %q, want %q", got, want)
+	}
+}
+
+func TestListAction_outputTemplateFormat(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	for _, f := range listCommand().Flags {
+		f.Apply(set)
+	}
+	if err := set.Parse([]string{"--format", "jsonl", "--output-template", "{{ .Name }}"}); err != nil {
+		t.Fatal(err)
+	}
+	err := listAction(cli.NewContext(&cli.App{}, set, nil))
+	if err == nil || !strings.Contains(err.Error(), "output-template") {
+		t.Errorf("listAction() error = %v, want incompatible flags error", err)
+	}
+}
@@ -10,6 +10,11 @@ func Command() cli.Command {
 		UsageText: "step ca provisioner <subcommand> [arguments] [global-flags] [subcommand-flags]",
 		Subcommands: cli.Commands{
 			listCommand(),
+			countCommand(),
+			auditCommand(),
+			diffCommand(),
+			applyCommand(),
+			whoamiCommand(),
 			getEncryptedKeyCommand(),
 			addCommand(),
 			removeCommand(),
@@ -56,6 +61,31 @@ List the active provisioners:
 $ step ca provisioner list
 '''
 
+Print the number of active provisioners:
+'''
+$ step ca provisioner count
+'''
+
+Review the provisioners for risky settings:
+'''
+$ step ca provisioner audit
+'''
+
+Compare a file of provisioners with the provisioners in the CA:
+'''
+$ step ca provisioner diff provisioners.json
+'''
+
+Make the provisioners in the CA match a file:
+'''
+$ step ca provisioner apply provisioners.json
+'''
+
+Print the CA and admin identity used to manage the provisioners:
+'''
+$ step ca provisioner whoami
+'''
+
 Retrieve the encrypted private jwk for the given kid:
 '''
 $ step ca provisioner jwe-key 1234 --ca-url https://127.0.0.1 --root ./root.crt
@@ -6,8 +6,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/smallstep/certificates/authority/config"
 	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
 )
 
@@ -17,12 +17,15 @@ func removeCommand() cli.Command {
 		Action: cli.ActionFunc(removeAction),
 		Usage:  "remove one, or more, provisioners from the CA configuration",
 		UsageText: `**step ca provisioner remove** <name>
-[**--kid**=<kid>] [**--config**=<file>] [**--all**]`,
+[**--kid**=<kid>] [**--config**=<file>] [**--all**] [**--no-backup**]
+[**--lock-timeout**=<duration>]`,
 		Flags: []cli.Flag{
 			cli.StringFlag{
 				Name:  "ca-config",
 				Usage: "The <file> containing the CA configuration.",
 			},
+			noBackupFlag,
+			lockTimeoutFlag,
 			cli.StringFlag{
 				Name:  "kid",
 				Usage: "The <kid> (Key ID) of the JWK provisioner key to be removed.",
@@ -67,10 +70,18 @@ and must be one of:
 		},
 		Description: `**step ca provisioner remove** removes one or more provisioners
 from the configuration and writes the new configuration back to the CA config.
+Before modifying the CA config, a backup is written to <file>.bak.<timestamp>
+unless **--no-backup** is used. The CA config is locked while it is modified,
+if another process holds the lock the command waits up to **--lock-timeout**.
 
 To pick up the new configuration you must SIGHUP (kill -1 <pid>) or restart the
 step-ca process.
 
+## EXIT CODES
+
+This command returns '0' on success, '3' if no provisioners match the given
+name and flags, and '1' for any other error.
+
 ## POSITIONAL ARGUMENTS
 
 <name>
@@ -147,6 +158,16 @@ func removeAction(ctx *cli.Context) error {
 		return errs.RequiredOrFlag(ctx, "all", "kid", "client-id", "type")
 	}
 
+	if err := checkConfig(caCfg); err != nil {
+		return err
+	}
+
+	unlock, err := lockConfig(ctx, caCfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	c, err := config.LoadConfiguration(caCfg)
 	if err != nil {
 		return errors.Wrapf(err, "error loading configuration")
@@ -184,18 +205,19 @@ func removeAction(ctx *cli.Context) error {
 	if !found {
 		switch {
 		case kid != "":
-			return errors.Errorf("no provisioners with name=%s and kid=%s found", name, kid)
+			err = errors.Errorf("no provisioners with name=%s and kid=%s found", name, kid)
 		case clientID != "":
-			return errors.Errorf("no provisioners with name=%s and client-id=%s found", name, clientID)
+			err = errors.Errorf("no provisioners with name=%s and client-id=%s found", name, clientID)
 		case typ != "":
-			return errors.Errorf("no provisioners with name=%s and type=%s found", name, typ)
+			err = errors.Errorf("no provisioners with name=%s and type=%s found", name, typ)
 		default:
-			return errors.Errorf("no provisioners with name %s found", name)
+			err = errors.Errorf("no provisioners with name %s found", name)
 		}
+		return errs.NewExitError(err, notFoundExitCode)
 	}
 
 	c.AuthorityConfig.Provisioners = provisioners
-	if err := c.Save(caCfg); err != nil {
+	if err := saveConfig(ctx, c, caCfg); err != nil {
 		return err
 	}
 
@@ -204,6 +226,10 @@ func removeAction(ctx *cli.Context) error {
 	return nil
 }
 
+// notFoundExitCode is the exit code used when there are no provisioners to
+// remove.
+const notFoundExitCode = 3
+
 // isProvisionerType returns true if p.GetType() is equal to typ. If typ is
 // empty it will always return true.
 func isProvisionerType(p provisioner.Interface, typ string) bool {
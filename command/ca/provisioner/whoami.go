@@ -0,0 +1,201 @@
+package provisioner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/smallstep/certificates/authority/config"
+	"github.com/smallstep/certificates/pki"
+	"github.com/smallstep/cli/crypto/pemutil"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+func whoamiCommand() cli.Command {
+	return cli.Command{
+		Name:   "whoami",
+		Action: cli.ActionFunc(whoamiAction),
+		Usage:  "print the CA and admin identity used to manage the provisioners",
+		UsageText: `**step ca provisioner whoami**
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-config**=<file>] [**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.CaConfig,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step ca provisioner whoami** prints how the flags, environment variables
+and defaults file resolve to the CA, the backend and the admin identity used to
+manage the provisioners, without connecting to the CA. It helps to debug
+authentication issues.
+
+The backend is the ca.json file given by **--ca-config** if it exists and does
+not set **enableAdmin**, or the admin API of the CA otherwise, as the CA then
+loads the provisioners from its database.
+
+The admin API is used by the **step beta ca provisioner** commands, and the
+ca.json file is modified by **step ca provisioner add** and
+**step ca provisioner remove**.
+
+Passwords and the output of **--password-command** are never printed.
+
+## EXAMPLES
+
+Print the identity used in the current context:
+'''
+$ step ca provisioner whoami
+'''
+
+Print the identity used with an admin certificate:
+'''
+$ step ca provisioner whoami --admin-cert admin.crt --admin-key admin.key
+'''`,
+	}
+}
+
+func whoamiAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 0); err != nil {
+		return err
+	}
+
+	fields, err := whoami(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := new(tabwriter.Writer)
+	// Format in tab-separated columns with a tab stop of 8.
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s:\t%s\n", f.name, f.value)
+	}
+	return w.Flush()
+}
+
+// whoamiField is one of the values printed by whoami.
+type whoamiField struct {
+	name  string
+	value string
+}
+
+// whoami returns the CA and admin identity resolved from the flags. It does
+// not include any secret.
+func whoami(ctx *cli.Context) ([]whoamiField, error) {
+	var fields []whoamiField
+	add := func(name, value string) {
+		fields = append(fields, whoamiField{name: name, value: value})
+	}
+
+	caURL, err := flags.ParseCaURLIfExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caURL == "" {
+		add("CA URL", "not set")
+	} else {
+		add("CA URL", caURL+flagSource(ctx, "ca-url"))
+	}
+
+	root := ctx.String("root")
+	switch {
+	case root != "":
+		add("Root", root+flagSource(ctx, "root"))
+	case fileExists(pki.GetRootCAPath()):
+		add("Root", pki.GetRootCAPath())
+	default:
+		add("Root", "not set")
+	}
+
+	caConfig := ctx.String("ca-config")
+	if fileExists(caConfig) {
+		add("CA config", caConfig+flagSource(ctx, "ca-config"))
+	} else {
+		add("CA config", caConfig+" (not found)")
+	}
+	add("Backend", whoamiBackend(caConfig))
+
+	if certFile := ctx.String("admin-cert"); certFile != "" {
+		add("Admin mode", "admin certificate")
+		cert, err := pemutil.ReadCertificate(certFile)
+		if err != nil {
+			return nil, err
+		}
+		add("Admin certificate", certFile+flagSource(ctx, "admin-cert"))
+		add("Admin subject", cert.Subject.CommonName)
+		add("Admin expires", cert.NotAfter.Format(time.RFC3339))
+		if keyFile := ctx.String("admin-key"); keyFile != "" {
+			add("Admin key", keyFile+flagSource(ctx, "admin-key"))
+		} else {
+			add("Admin key", "not set")
+		}
+	} else {
+		add("Admin mode", "credentials generated with a provisioner")
+		if name := ctx.String("admin-provisioner"); name != "" {
+			add("Admin provisioner", name+flagSource(ctx, "admin-provisioner"))
+		} else {
+			add("Admin provisioner", "not set, it will be prompted")
+		}
+		if subject := ctx.String("admin-subject"); subject != "" {
+			add("Admin subject", subject+flagSource(ctx, "admin-subject"))
+		} else {
+			add("Admin subject", "not set, it will be prompted")
+		}
+	}
+
+	switch {
+	case ctx.String("password-file") != "":
+		add("Password", "read from "+ctx.String("password-file")+flagSource(ctx, "password-file"))
+	case ctx.String("password-command") != "":
+		add("Password", "read from --password-command")
+	default:
+		add("Password", "not set, it will be prompted if required")
+	}
+
+	return fields, nil
+}
+
+// whoamiBackend returns the backend used to manage the provisioners: the
+// ca.json file if it exists and does not enable the admin API, or the admin
+// API otherwise.
+func whoamiBackend(caConfig string) string {
+	if !fileExists(caConfig) {
+		return "admin API (ca.json not found)"
+	}
+	c, err := config.LoadConfiguration(caConfig)
+	if err != nil {
+		return "unknown (" + err.Error() + ")"
+	}
+	if c.AuthorityConfig != nil && c.AuthorityConfig.EnableAdmin {
+		return "admin API (enableAdmin is set in ca.json)"
+	}
+	return "ca.json"
+}
+
+// flagSource returns a suffix with the environment variable used to set the
+// given flag, or an empty string if the value does not come from the
+// environment.
+func flagSource(ctx *cli.Context, name string) string {
+	envVar := "STEP_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if v := os.Getenv(envVar); v != "" && v == ctx.String(name) {
+		return " (from " + envVar + ")"
+	}
+	return ""
+}
+
+// fileExists returns true if the given file exists.
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return filename != "" && err == nil
+}
@@ -0,0 +1,88 @@
+package provisioner
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestWhoami(t *testing.T) {
+	t.Setenv("STEP_ADMIN_SUBJECT", "admin@example.com")
+
+	set := flag.NewFlagSet("test", 0)
+	for _, name := range []string{"ca-url", "root", "ca-config", "admin-cert", "admin-key",
+		"admin-provisioner", "admin-subject", "password-file", "password-command"} {
+		set.String(name, "", "")
+	}
+	ctx := cli.NewContext(&cli.App{}, set, nil)
+	for name, value := range map[string]string{
+		"ca-url":            "ca.example.com",
+		"root":              "root.crt",
+		"ca-config":         "does-not-exist.json",
+		"admin-provisioner": "admin",
+		"admin-subject":     "admin@example.com",
+		"password-command":  "echo secret",
+	} {
+		if err := ctx.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fields, err := whoami(ctx)
+	if err != nil {
+		t.Fatalf("whoami() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range fields {
+		got[f.name] = f.value
+		if strings.Contains(f.value, "secret") {
+			t.Errorf("whoami() %s = %q, it contains the password command", f.name, f.value)
+		}
+	}
+	want := map[string]string{
+		"CA URL":            "https://ca.example.com",
+		"Root":              "root.crt",
+		"CA config":         "does-not-exist.json (not found)",
+		"Backend":           "admin API (ca.json not found)",
+		"Admin mode":        "credentials generated with a provisioner",
+		"Admin provisioner": "admin",
+		"Admin subject":     "admin@example.com (from STEP_ADMIN_SUBJECT)",
+		"Password":          "read from --password-command",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("whoami() %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestWhoamiBackend(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	tests := []struct {
+		name     string
+		caConfig string
+		want     string
+	}{
+		{"not found", filepath.Join(dir, "missing.json"), "admin API (ca.json not found)"},
+		{"ca.json", write("ca.json", `{"authority":{"provisioners":[]}}`), "ca.json"},
+		{"enableAdmin", write("admin.json", `{"authority":{"enableAdmin":true}}`), "admin API (enableAdmin is set in ca.json)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := whoamiBackend(tt.caConfig); got != tt.want {
+				t.Errorf("whoamiBackend() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,30 +1,37 @@
 package provisionerbeta
 
 import (
-	"bytes"
-	"crypto/ecdsa"
-	"crypto/ed25519"
-	"crypto/rsa"
+	"context"
+	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority"
+	adminAPI "github.com/smallstep/certificates/authority/admin/api"
+	"github.com/smallstep/certificates/authority/config"
 	"github.com/smallstep/certificates/authority/provisioner"
-	"github.com/smallstep/cli/crypto/pemutil"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/certificates/pki"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/jose"
 	"github.com/smallstep/cli/utils"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
 	"go.step.sm/linkedca"
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 func addCommand() cli.Command {
@@ -33,53 +40,68 @@ func addCommand() cli.Command {
 		Action: cli.ActionFunc(addAction),
 		Usage:  "add a provisioner",
 		UsageText: `**step beta ca provisioner add** <name> **--type**=JWK [**--public-key**=<file>]
-[**--private-key**=<file>] [**--create**] [**--password-file**=<file>]
+[**--private-key**=<file>] [**--create**] [**--password-file**=<file>] [**--raw-key**]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 **step beta ca provisioner add** <name> **--type**=OIDC
 [**--client-id**=<id>] [**--client-secret**=<secret>]
-[**--configuration-endpoint**=<url>] [**--domain**=<domain>]
+[**--configuration-endpoint**=<url>] [**--configuration-snapshot**=<file>] [**--domain**=<domain>]
 [**--admin**=<email>]...
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 
 **step beta ca provisioner add** <name> **--type**=X5C **--x5c-root**=<file>
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 **step beta ca provisioner add** <name> **--type**=SSHPOP
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 **step beta ca provisioner add** <name> **--type**=Nebula **--nebula-root**=<file>
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
-**step beta ca provisioner add** <name> **--type**=K8SSA [**--public-key**=<file>]
+**step beta ca provisioner add** <name> **--type**=K8SSA [**--pem-keys**=<file>]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 **step beta ca provisioner add** <name> **--type**=[AWS|Azure|GCP]
 [**--aws-account**=<id>] [**--gcp-service-account**=<name>] [**--gcp-project**=<name>]
-[**--azure-tenant**=<id>] [**--azure-resource-group**=<name>]
+[**--azure-tenant**=<id>] [**--azure-audience**=<uri>] [**--azure-resource-group**=<name>]
 [**--instance-age**=<duration>] [**--iid-roots**=<file>]
-[**--disable-custom-sans**] [**--disable-trust-on-first-use**]
+[**--disable-custom-sans**] [**--disable-trust-on-first-use**] [**--default-san**=<san>]...
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
-**step beta ca provisioner add** <name> **--type**=ACME [**--force-cn**] [**--require-eab**]
+**step beta ca provisioner add** <name> **--type**=ACME [**--force-cn**] [**--require-eab**] [**--create-eab-key**]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
+
+**step beta ca provisioner add** <name> **--extends**=<name> [**--type**=<type>]
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
+
+**step beta ca provisioner add** **--from-dir**=<dir> [**--fail-fast**] [**--force**] [**--dry-run**] [**--wait**]
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
+
+**step beta ca provisioner add** **--from-ca-config**=<file> [**--fail-fast**]
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 **step beta ca provisioner add** <name> **--type**=SCEP [**--force-cn**] [**--challenge**=<challenge>]
 [**--capabilities**=<capabilities>] [**--include-root**] [**--min-public-key-length**=<length>]
@@ -129,11 +151,19 @@ func addCommand() cli.Command {
 `},
 			x509TemplateFlag,
 			x509TemplateDataFlag,
+			x509TemplateDataJSONFlag,
 			sshTemplateFlag,
 			sshTemplateDataFlag,
+			sshTemplateDataJSONFlag,
+			expandEnvFlag,
+			insecureTemplateFlag,
+			maxDurFlag,
 			x509MinDurFlag,
 			x509MaxDurFlag,
 			x509DefaultDurFlag,
+			minTLSCertDurationFlag,
+			maxTLSCertDurationFlag,
+			defaultTLSCertDurationFlag,
 			sshUserMinDurFlag,
 			sshUserMaxDurFlag,
 			sshUserDefaultDurFlag,
@@ -142,8 +172,10 @@ func addCommand() cli.Command {
 			sshHostDefaultDurFlag,
 			disableRenewalFlag,
 			allowRenewalAfterExpiryFlag,
+			claimsJSONFlag,
 			enableX509Flag,
 			enableSSHFlag,
+			enableSSHCAFlag,
 
 			// JWK provisioner flags
 			cli.BoolFlag{
@@ -157,7 +189,15 @@ func addCommand() cli.Command {
 			cli.StringFlag{
 				Name: "public-key",
 				Usage: `The <file> containing the JWK public key. Or, a <file>
-containing one or more PEM formatted keys, if used with the K8SSA provisioner.`,
+containing one or more PEM formatted keys, if used with the K8SSA provisioner.
+Prefer **--pem-keys** for K8SSA provisioners.`,
+			},
+			cli.BoolFlag{
+				Name: "raw-key",
+				Usage: `Store the JWK in the **--public-key** file exactly as it is, without adding a
+key id or rewriting its encoding. The file must contain a public JWK with a key
+id (kid). Only the syntax of the JWK is validated, a key that the CA or the
+clients cannot use might be stored. Requires **--public-key**.`,
 			},
 
 			// OIDC provisioner flags
@@ -174,14 +214,23 @@ containing one or more PEM formatted keys, if used with the K8SSA provisioner.`,
 				Usage: `The callback <address> used in the OpenID Connect flow (e.g. \":10000\")`,
 			},
 			cli.StringFlag{
-				Name:  "configuration-endpoint",
-				Usage: `OpenID Connect configuration <url>.`,
+				Name: "configuration-endpoint",
+				Usage: `OpenID Connect configuration <url>. The discovery document is retrieved and
+validated before creating the provisioner. The system roots and the CA root in
+**--root** are trusted to connect to the <url>. Use **--insecure** to allow an
+http <url>.`,
 			},
+			oidcConfigurationSnapshotFlag,
 			cli.StringSliceFlag{
 				Name: "admin",
 				Usage: `The <email> of an admin user in an OpenID Connect provisioner, this user
 will not have restrictions in the certificates to sign. Use the
 '--admin' flag multiple times to configure multiple administrators.`,
+			},
+			cli.StringSliceFlag{
+				Name: "domain",
+				Usage: `The <domain> used to validate the email claim in an OpenID Connect provisioner.
+Use the '--domain' flag multiple times to configure multiple domains.`,
 			},
 			cli.StringSliceFlag{
 				Name: "group",
@@ -194,11 +243,10 @@ Use the '--group' flag multiple times to configure multiple groups.`,
 			},
 
 			// X5C provisioner flags
-			cli.StringFlag{
-				Name: "x5c-root",
-				Usage: `Root certificate (chain) <file> used to validate the signature on X5C
-provisioning tokens.`,
-			},
+			x5cRootFlag,
+
+			// K8SSA provisioner flags
+			pemKeysFlag,
 
 			// Nebula provisioner flags
 			nebulaRootFlag,
@@ -206,6 +254,12 @@ provisioning tokens.`,
 			// ACME provisioner flags
 			forceCNFlag,
 			requireEABFlag,
+			cli.BoolFlag{
+				Name: "create-eab-key",
+				Usage: `Create an ACME External Account Binding key for the new provisioner and print
+it, so the provisioner can be used right away. Requires an ACME provisioner with
+**--require-eab**. Without this flag, the command to create a key is printed.`,
+			},
 
 			// SCEP provisioner flags
 			scepChallengeFlag,
@@ -217,21 +271,74 @@ provisioning tokens.`,
 			// Cloud provisioner flags
 			awsAccountFlag,
 			azureTenantFlag,
+			azureAudienceFlag,
 			azureResourceGroupFlag,
 			azureSubscriptionIDFlag,
 			azureObjectIDFlag,
 			gcpServiceAccountFlag,
 			gcpProjectFlag,
 			instanceAgeFlag,
+			instanceAgeWarningFlag,
 			iidRootsFlag,
 			disableCustomSANsFlag,
 			disableTOFUFlag,
+			defaultSANFlag,
+
+			// Bulk flags
+			cli.StringFlag{
+				Name: "from-dir",
+				Usage: `Create a provisioner for each JSON or YAML <file> in the given directory.
+The files use the format generated by **step beta ca provisioner export**.
+**--force**, **--dry-run** and **--wait** apply to each provisioner.`,
+			},
+			cli.StringFlag{
+				Name: "from-ca-config",
+				Usage: `Create a provisioner for each provisioner in the "authority" section of the
+given CA configuration <file>. Provisioners that already exist are skipped.
+This migrates the provisioners in a ca.json to the admin API. A warning is
+printed for the provisioners with a maximum certificate duration longer than the
+global maximum in the file, or the default of the CA if it is not set.
+**--dry-run** and **--wait** apply to each provisioner. It cannot be used
+with **--from-dir**.`,
+			},
+			cli.BoolFlag{
+				Name: "fail-fast",
+				Usage: `Stop at the first provisioner that cannot be created when using **--from-dir**
+or **--from-ca-config**.`,
+			},
 
+			cli.StringFlag{
+				Name: "extends",
+				Usage: `Use the templates and claims of the existing provisioner with the
+given <name> as the base of the new provisioner. The flags are applied on top of
+them, as with **step beta ca provisioner update**. The new provisioner uses the
+type of the base provisioner unless **--type** is set, but it never copies its
+keys or other type specific configuration.`,
+			},
+			cli.BoolFlag{
+				Name: "replace",
+				Usage: `Update the provisioner if a provisioner with the same name already exists,
+instead of failing. The existing provisioner must have the same type.`,
+			},
+			cli.BoolFlag{
+				Name: "force",
+				Usage: `Remove the existing provisioner and create it again if the CA reports that a
+provisioner with the same name already exists. Unlike **--replace**, the
+provisioner gets a new id and the configuration of the existing one is lost.`,
+			},
+			dryRunFlag,
+			waitFlag,
+			waitIntervalFlag,
+			waitTimeoutFlag,
 			flags.AdminCert,
 			flags.AdminKey,
 			flags.AdminProvisioner,
 			flags.AdminSubject,
 			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
 			flags.CaURL,
 			flags.Root,
 			flags.Context,
@@ -250,16 +357,44 @@ Create a JWK provisioner with newly generated keys and a template for x509 certi
 step beta ca provisioner add cicd --type JWK --create --x509-template ./templates/example.tpl
 '''
 
+Create a JWK provisioner with a template for x509 certificates and inline template data:
+'''
+step beta ca provisioner add cicd --type JWK --create --x509-template ./templates/example.tpl \
+  --x509-template-data-json '{"organization": "Smallstep"}'
+'''
+
+Create a JWK provisioner with a template retrieved from a URL:
+'''
+step beta ca provisioner add cicd --type JWK --create \
+  --x509-template https://templates.example.com/x509/cicd.tpl
+'''
+
 Create a JWK provisioner with duration claims:
 '''
 step beta ca provisioner add cicd --type JWK --create --x509-min-dur 20m --x509-default-dur 48h --ssh-user-min-dur 17m --ssh-host-default-dur 16h
 '''
 
+Create a JWK provisioner with a maximum duration of 12h for all certificates,
+except for ssh host certificates:
+'''
+step beta ca provisioner add cicd --type JWK --create --max-dur 12h --ssh-host-max-dur 720h
+'''
+
+Create a JWK provisioner with the claims in a JSON file:
+'''
+step beta ca provisioner add cicd --type JWK --create --claims-json claims.json
+'''
+
 Create a JWK provisioner with existing keys:
 '''
 step beta ca provisioner add jane@doe.com --type JWK --public-key jwk.pub --private-key jwk.priv
 '''
 
+Create a JWK provisioner storing the public key created by an external tool as it is:
+'''
+step beta ca provisioner add jane@doe.com --type JWK --public-key jwk.pub --raw-key
+'''
+
 Create an OIDC provisioner:
 '''
 step beta ca provisioner add Google --type OIDC --ssh \
@@ -273,6 +408,11 @@ Create an X5C provisioner:
 step beta ca provisioner add x5c --type X5C --x5c-root x5c_ca.crt
 '''
 
+Create an X5C provisioner trusting the CA certificates in multiple files:
+'''
+step beta ca provisioner add x5c --type X5C --x5c-root x5c_ca.crt --x5c-root other_ca.crt
+'''
+
 Create an ACME provisioner:
 '''
 step beta ca provisioner add acme --type ACME
@@ -283,9 +423,19 @@ Create an ACME provisioner, forcing a CN and requiring EAB:
 step beta ca provisioner add acme --type ACME --force-cn --require-eab
 '''
 
+Create an ACME provisioner requiring EAB and its first EAB key:
+'''
+step beta ca provisioner add acme --type ACME --require-eab --create-eab-key
+'''
+
 Create an K8SSA provisioner:
 '''
-step beta ca provisioner add kube --type K8SSA --ssh --public-key key.pub
+step beta ca provisioner add kube --type K8SSA --ssh --pem-keys key.pub
+'''
+
+Create an K8SSA provisioner trusting the service account keys of two clusters:
+'''
+step beta ca provisioner add kube --type K8SSA --pem-keys cluster1.pub --pem-keys cluster2.pub
 '''
 
 Create an SSHPOP provisioner for renewing SSH host certificates:")
@@ -295,7 +445,7 @@ step beta ca provisioner add sshpop --type SSHPOP
 
 Create a SCEP provisioner with 'secret' challenge and AES-256-CBC encryption:
 '''
-step beta ca provisioner add my_scep_provisioner --type SCEP --challenge secret --encryption-algorithm-identifier 2
+step beta ca provisioner add my_scep_provisioner --type SCEP --challenge secret --encryption-algorithm-identifier aes-256-cbc
 '''
 
 Create an Azure provisioner with two resource groups, one subscription ID and one object ID:
@@ -320,93 +470,132 @@ $ step beta ca provisioner add Amazon --type AWS \
   --aws-account 123456789 --disable-custom-sans --disable-trust-on-first-use
 '''
 
+Create an AWS provisioner that always includes a SAN in the certificates:
+'''
+$ step beta ca provisioner add Amazon --type AWS \
+  --aws-account 123456789 --disable-custom-sans --default-san internal.example.com
+'''
+
 Create an AWS provisioner that will use a custom certificate to validate the instance
 identity documents:
 '''
 $ step beta ca provisioner add Amazon --type AWS \
   --aws-account 123456789 --iid-roots $(step path)/certs/aws.crt
+'''
+
+Create a JWK provisioner, or replace it if it already exists:
+'''
+$ step beta ca provisioner add cicd --type JWK --public-key ./cicd.pub.json --replace
+'''
+
+Create an ACME provisioner and wait until it is visible in the CA:
+'''
+$ step beta ca provisioner add acme --type ACME --wait --wait-timeout 1m
+'''
+
+Create a JWK provisioner, removing and creating it again with a new key if it
+already exists:
+'''
+$ step beta ca provisioner add cicd --type JWK --create --force
+'''
+
+Create all the provisioners exported in a directory:
+'''
+$ step beta ca provisioner add --from-dir ./provisioners
+'''
+
+Validate all the provisioners exported in a directory without creating them:
+'''
+$ step beta ca provisioner add --from-dir ./provisioners --dry-run
+'''
+
+Create an ACME provisioner with the templates and claims of an existing
+one, but a different maximum duration:
+'''
+$ step beta ca provisioner add acme-team-b --extends acme-team-a --x509-max-dur 48h
+'''
+
+Migrate the provisioners in a CA configuration file to the admin API:
+'''
+$ step beta ca provisioner add --from-ca-config $(step path)/config/ca.json
+'''
+
+Print the JWK provisioner that would be created without adding it to the CA:
+'''
+$ step beta ca provisioner add cicd --type JWK --create --dry-run
 '''`,
 	}
 }
 
 func addAction(ctx *cli.Context) (err error) {
-	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+	if err = applyDeprecatedDurFlags(ctx, os.Stderr); err != nil {
 		return err
 	}
 
-	x509TemplateFile := ctx.String("x509-template")
-	x509TemplateDataFile := ctx.String("x509-template-data")
-	sshTemplateFile := ctx.String("ssh-template")
-	sshTemplateDataFile := ctx.String("ssh-template-data")
-
-	args := ctx.Args()
-
-	typ, ok := linkedca.Provisioner_Type_value[strings.ToUpper(ctx.String("type"))]
-	if !ok {
-		return fmt.Errorf("unsupported provisioner type %s", ctx.String("type"))
+	if ctx.String("from-dir") != "" && ctx.String("from-ca-config") != "" {
+		return errs.MutuallyExclusiveFlags(ctx, "from-dir", "from-ca-config")
 	}
-
-	p := &linkedca.Provisioner{
-		Name: args.Get(0),
-	}
-
-	// Read x509 template if passed
-	p.X509Template = &linkedca.Template{}
-	if x509TemplateFile != "" {
-		b, err := utils.ReadFile(x509TemplateFile)
-		if err != nil {
+	if dir := ctx.String("from-dir"); dir != "" {
+		if err := errs.NumberOfArguments(ctx, 0); err != nil {
 			return err
 		}
-		p.X509Template.Template = b
+		return addFromDirAction(ctx, dir)
 	}
-	if x509TemplateDataFile != "" {
-		b, err := utils.ReadFile(x509TemplateDataFile)
-		if err != nil {
+	if filename := ctx.String("from-ca-config"); filename != "" {
+		if err := errs.NumberOfArguments(ctx, 0); err != nil {
 			return err
 		}
-		p.X509Template.Data = b
-	}
-	// Read ssh template if passed
-	p.SshTemplate = &linkedca.Template{}
-	if sshTemplateFile != "" {
-		b, err := utils.ReadFile(sshTemplateFile)
-		if err != nil {
-			return err
+		// Existing provisioners are always skipped.
+		if ctx.Bool("force") {
+			return errs.IncompatibleFlagWithFlag(ctx, "force", "from-ca-config")
 		}
-		p.SshTemplate.Template = b
+		return addFromCAConfigAction(ctx, filename)
 	}
-	if sshTemplateDataFile != "" {
-		b, err := utils.ReadFile(sshTemplateDataFile)
-		if err != nil {
+	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+		return err
+	}
+
+	args := ctx.Args()
+
+	// Fetch the base provisioner first, its type is used by default.
+	var (
+		client *ca.AdminClient
+		base   *linkedca.Provisioner
+	)
+	typeName := ctx.String("type")
+	if name := ctx.String("extends"); name != "" {
+		if client, err = cautils.NewAdminClient(ctx); err != nil {
 			return err
 		}
-		p.SshTemplate.Data = b
+		if base, err = client.GetProvisioner(ca.WithProvisionerName(name)); err != nil {
+			return notFoundExitError(err)
+		}
+		if !ctx.IsSet("type") {
+			typeName = base.Type.String()
+		}
 	}
 
-	p.Claims = &linkedca.Claims{
-		X509: &linkedca.X509Claims{
-			Durations: &linkedca.Durations{
-				Min:     ctx.String("x509-min-dur"),
-				Max:     ctx.String("x509-max-dur"),
-				Default: ctx.String("x509-default-dur"),
-			},
-			Enabled: !(ctx.IsSet("x509") && !ctx.Bool("x509")),
-		},
-		Ssh: &linkedca.SSHClaims{
-			UserDurations: &linkedca.Durations{
-				Min:     ctx.String("ssh-user-min-dur"),
-				Max:     ctx.String("ssh-user-max-dur"),
-				Default: ctx.String("ssh-user-default-dur"),
-			},
-			HostDurations: &linkedca.Durations{
-				Min:     ctx.String("ssh-host-min-dur"),
-				Max:     ctx.String("ssh-host-max-dur"),
-				Default: ctx.String("ssh-host-default-dur"),
-			},
-			Enabled: !(ctx.IsSet("ssh") && !ctx.Bool("ssh")),
-		},
-		DisableRenewal:          ctx.Bool("disable-renewal"),
-		AllowRenewalAfterExpiry: ctx.Bool("allow-renewal-after-expiry"),
+	typ, ok := linkedca.Provisioner_Type_value[strings.ToUpper(typeName)]
+	if !ok {
+		return fmt.Errorf("unsupported provisioner type %s", typeName)
+	}
+	if err := validateEABFlags(ctx, linkedca.Provisioner_Type(typ)); err != nil {
+		return err
+	}
+	if err := validateCloudFlags(ctx, linkedca.Provisioner_Type(typ)); err != nil {
+		return err
+	}
+
+	p := &linkedca.Provisioner{
+		Name: args.Get(0),
+	}
+	if base != nil {
+		err = extendProvisioner(ctx, p, base)
+	} else {
+		err = newProvisionerSettings(ctx, p)
+	}
+	if err != nil {
+		return err
 	}
 
 	switch linkedca.Provisioner_Type(typ) {
@@ -444,54 +633,372 @@ func addAction(ctx *cli.Context) (err error) {
 		p.Type = linkedca.Provisioner_NEBULA
 		p.Details, err = createNebulaDetails(ctx)
 	default:
-		return fmt.Errorf("unsupported provisioner type %s", ctx.String("type"))
+		return fmt.Errorf("unsupported provisioner type %s", typeName)
 	}
 	if err != nil {
 		return err
 	}
+	if ctx.Bool("create-eab-key") && !p.Details.GetACME().GetRequireEab() {
+		return errors.New("flag '--create-eab-key' requires an ACME provisioner with the '--require-eab' flag")
+	}
 
-	client, err := cautils.NewAdminClient(ctx)
-	if err != nil {
+	if ctx.Bool("dry-run") {
+		if err := printBackend(ctx, os.Stderr); err != nil {
+			return err
+		}
+		return printProvisioner(p)
+	}
+
+	if client == nil {
+		if client, err = cautils.NewAdminClient(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("replace") {
+		var old *linkedca.Provisioner
+		if old, err = findProvisioner(client, p.Name); err != nil {
+			return err
+		}
+		if old != nil {
+			if old.Type != p.Type {
+				return errors.Errorf("cannot replace provisioner %s of type %s with a provisioner of type %s", p.Name, old.Type, p.Type)
+			}
+			p.Id = old.Id
+			p.AuthorityId = old.AuthorityId
+			p.CreatedAt = old.CreatedAt
+			p.DeletedAt = old.DeletedAt
+			if err := client.UpdateProvisioner(p.Name, p); err != nil {
+				return err
+			}
+			if err := waitForChange(ctx, client, old, p); err != nil {
+				return err
+			}
+			ui.Printf("Provisioner %s updated.\n", p.Name)
+			return printProvisioner(p)
+		}
+	}
+
+	if p, err = createProvisioner(client, p, ctx.Bool("force"), os.Stderr); err != nil {
 		return err
 	}
+	if err := waitForChange(ctx, client, nil, p); err != nil {
+		return err
+	}
+	if ctx.Bool("replace") {
+		ui.Printf("Provisioner %s created.\n", p.Name)
+	}
 
-	if p, err = client.CreateProvisioner(p); err != nil {
+	if err := printProvisioner(p); err != nil {
 		return err
 	}
+	return printEABFollowUp(client, p, ctx.Bool("create-eab-key"), os.Stderr)
+}
+
+// eabKeyCreator is the part of the admin client used to create ACME External
+// Account Binding keys.
+type eabKeyCreator interface {
+	CreateExternalAccountKey(provisionerName string, eakRequest *adminAPI.CreateExternalAccountKeyRequest) (*linkedca.EABKey, error)
+}
 
-	var buf bytes.Buffer
-	b, err := protojson.Marshal(p)
+// printEABFollowUp prints the next step required to use a new ACME
+// provisioner that requires External Account Binding: the command to create
+// a key or, if create is true, a new key. It does nothing for other
+// provisioners.
+func printEABFollowUp(client eabKeyCreator, p *linkedca.Provisioner, create bool, w io.Writer) error {
+	if !p.Details.GetACME().GetRequireEab() {
+		return nil
+	}
+	if !create {
+		fmt.Fprintf(w, "\nProvisioner %s requires External Account Binding, create a key with:\n", p.Name)
+		fmt.Fprintf(w, "  step beta ca acme eab add %s\n", p.Name)
+		return nil
+	}
+	eak, err := client.CreateExternalAccountKey(p.Name, &adminAPI.CreateExternalAccountKeyRequest{})
 	if err != nil {
+		return errors.Wrapf(err, "provisioner %s was created, but there was an error creating the ACME EAB key", p.Name)
+	}
+	fmt.Fprintf(w, "\nACME External Account Binding key created.\n")
+	fmt.Fprintf(w, "Key ID: %s\n", eak.Id)
+	fmt.Fprintf(w, "Key (base64, raw url encoded): %s\n", base64.RawURLEncoding.EncodeToString(eak.HmacKey))
+	return nil
+}
+
+// newProvisionerSettings sets the templates and claims of a new provisioner
+// using the flags.
+func newProvisionerSettings(ctx *cli.Context, p *linkedca.Provisioner) (err error) {
+	// Read x509 template if passed
+	p.X509Template = &linkedca.Template{}
+	if ctx.String("x509-template") != "" {
+		b, err := readTemplate(ctx, "x509-template")
+		if err != nil {
+			return err
+		}
+		p.X509Template.Template = b
+	}
+	if p.X509Template.Data, err = readTemplateData(ctx, "x509-template-data"); err != nil {
 		return err
 	}
-	if err := json.Indent(&buf, b, "", "  "); err != nil {
+	if err := updateDefaultSANs(ctx, p); err != nil {
+		return err
+	}
+	// Read ssh template if passed
+	p.SshTemplate = &linkedca.Template{}
+	if ctx.String("ssh-template") != "" {
+		b, err := readTemplate(ctx, "ssh-template")
+		if err != nil {
+			return err
+		}
+		p.SshTemplate.Template = b
+	}
+	if p.SshTemplate.Data, err = readTemplateData(ctx, "ssh-template-data"); err != nil {
 		return err
 	}
-	fmt.Println(buf.String())
 
+	p.Claims = &linkedca.Claims{
+		X509: &linkedca.X509Claims{
+			Durations: &linkedca.Durations{
+				Min:     ctx.String("x509-min-dur"),
+				Max:     ctx.String("x509-max-dur"),
+				Default: ctx.String("x509-default-dur"),
+			},
+			Enabled: !(ctx.IsSet("x509") && !ctx.Bool("x509")),
+		},
+		Ssh: &linkedca.SSHClaims{
+			UserDurations: &linkedca.Durations{
+				Min:     ctx.String("ssh-user-min-dur"),
+				Max:     ctx.String("ssh-user-max-dur"),
+				Default: ctx.String("ssh-user-default-dur"),
+			},
+			HostDurations: &linkedca.Durations{
+				Min:     ctx.String("ssh-host-min-dur"),
+				Max:     ctx.String("ssh-host-max-dur"),
+				Default: ctx.String("ssh-host-default-dur"),
+			},
+			Enabled: !(ctx.IsSet("ssh") && !ctx.Bool("ssh")),
+		},
+		DisableRenewal:          ctx.Bool("disable-renewal"),
+		AllowRenewalAfterExpiry: ctx.Bool("allow-renewal-after-expiry"),
+	}
+	if err := applyEnableSSHCA(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := applyClaimsJSON(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := applyMaxDur(ctx, p.Claims); err != nil {
+		return err
+	}
+	return validateClaims(ctx, p.Claims)
+}
+
+// extendProvisioner sets the templates and claims of a new provisioner
+// using the ones in the given base provisioner, and applies the flags on top
+// of them, like "update" does.
+func extendProvisioner(ctx *cli.Context, p, base *linkedca.Provisioner) error {
+	if base.X509Template != nil {
+		p.X509Template = proto.Clone(base.X509Template).(*linkedca.Template)
+	}
+	if base.SshTemplate != nil {
+		p.SshTemplate = proto.Clone(base.SshTemplate).(*linkedca.Template)
+	}
+	if base.Claims != nil {
+		p.Claims = proto.Clone(base.Claims).(*linkedca.Claims)
+	}
+
+	if err := updateTemplates(ctx, p); err != nil {
+		return err
+	}
+	if err := updateDefaultSANs(ctx, p); err != nil {
+		return err
+	}
+	updateClaims(ctx, p)
+	if err := applyEnableSSHCA(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := applyClaimsJSON(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := applyMaxDur(ctx, p.Claims); err != nil {
+		return err
+	}
+	return validateClaims(ctx, p.Claims)
+}
+
+// addFromDirAction creates a provisioner for each JSON or YAML file in the
+// given directory, and prints a summary with the result of each file.
+func addFromDirAction(ctx *cli.Context, dir string) error {
+	var files []string
+	for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return errors.Wrapf(err, "error reading %s", dir)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return errors.Errorf("error reading %s: no JSON or YAML files found", dir)
+	}
+	sort.Strings(files)
+
+	// The files are only validated with --dry-run, the CA is not contacted.
+	dryRun := ctx.Bool("dry-run")
+	var client *ca.AdminClient
+	if dryRun {
+		if err := printBackend(ctx, os.Stderr); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if client, err = cautils.NewAdminClient(ctx); err != nil {
+			return err
+		}
+	}
+
+	var created, failed int
+	for _, fn := range files {
+		p, err := readProvisioner(fn)
+		if err == nil && !dryRun {
+			if p, err = createProvisioner(client, p, ctx.Bool("force"), os.Stderr); err == nil {
+				err = waitForChange(ctx, client, nil, p)
+			}
+		}
+		if err != nil {
+			failed++
+			ui.Printf("✖ %s: %v\n", fn, err)
+			if ctx.Bool("fail-fast") {
+				break
+			}
+			continue
+		}
+		created++
+		if dryRun {
+			ui.Printf("✔ %s: provisioner %s would be created\n", fn, p.Name)
+		} else {
+			ui.Printf("✔ %s: provisioner %s created\n", fn, p.Name)
+		}
+	}
+
+	if dryRun {
+		ui.Printf("%d of %d provisioners would be created.\n", created, len(files))
+	} else {
+		ui.Printf("%d of %d provisioners created.\n", created, len(files))
+	}
+	if failed > 0 {
+		return errors.Errorf("error creating %d provisioners from %s", failed, dir)
+	}
+	return nil
+}
+
+// addFromCAConfigAction creates the provisioners in the given CA
+// configuration file. Provisioners that already exist are skipped. With
+// --dry-run, the CA is only used to find the existing provisioners.
+func addFromCAConfigAction(ctx *cli.Context, filename string) error {
+	c, err := config.LoadConfiguration(filename)
+	if err != nil {
+		return errors.Wrapf(err, "error loading configuration")
+	}
+	if c.AuthorityConfig == nil || len(c.AuthorityConfig.Provisioners) == 0 {
+		return errors.Errorf("error reading %s: no provisioners found", filename)
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	dryRun := ctx.Bool("dry-run")
+	if dryRun {
+		if err := printBackend(ctx, os.Stderr); err != nil {
+			return err
+		}
+	}
+	var created, skipped, failed int
+	for i, prov := range c.AuthorityConfig.Provisioners {
+		name := prov.GetName()
+		exists, err := provisionerExists(client, name)
+		if err != nil && i == 0 {
+			return errors.Wrap(err, "error connecting to the admin API, make sure it is "+
+				`enabled with "enableAdmin": true in the authority configuration of the CA`)
+		}
+		if err == nil && exists {
+			skipped++
+			ui.Printf("- %s: provisioner already exists, skipping\n", name)
+			continue
+		}
+		if err == nil {
+			var p *linkedca.Provisioner
+			if p, err = authority.ProvisionerToLinkedca(prov); err == nil {
+				p.Id = ""
+				for _, w := range permissiveClaimsWarnings(p.Claims, c.AuthorityConfig.Claims) {
+					fmt.Fprintf(os.Stderr, "Warning: %s: %s.\n", name, w)
+				}
+				if !dryRun {
+					if p, err = client.CreateProvisioner(p); err == nil {
+						err = waitForChange(ctx, client, nil, p)
+					}
+				}
+			}
+		}
+		if err != nil {
+			failed++
+			ui.Printf("✖ %s: %v\n", name, err)
+			if ctx.Bool("fail-fast") {
+				break
+			}
+			continue
+		}
+		created++
+		if dryRun {
+			ui.Printf("✔ %s: provisioner would be created\n", name)
+		} else {
+			ui.Printf("✔ %s: provisioner created\n", name)
+		}
+	}
+
+	if dryRun {
+		ui.Printf("%d of %d provisioners would be created, %d skipped.\n", created, len(c.AuthorityConfig.Provisioners), skipped)
+	} else {
+		ui.Printf("%d of %d provisioners created, %d skipped.\n", created, len(c.AuthorityConfig.Provisioners), skipped)
+	}
+	if failed > 0 {
+		return errors.Errorf("error creating %d provisioners from %s", failed, filename)
+	}
 	return nil
 }
 
 func createJWKDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
+	return newJWKDetails(ctx, ctx.Bool("create"))
+}
+
+// newJWKDetails returns the details of a JWK provisioner. If create is true a
+// new key pair is generated, if not the keys in the --public-key and
+// --private-key flags are used.
+func newJWKDetails(ctx *cli.Context, create bool) (*linkedca.ProvisionerDetails, error) {
 	var (
 		err      error
 		password string
 	)
 	if passwordFile := ctx.String("password-file"); len(passwordFile) > 0 {
-		password, err = utils.ReadStringPasswordFromFile(passwordFile)
-		if err != nil {
+		var b []byte
+		if b, err = utils.ReadPasswordFile(passwordFile); err != nil {
 			return nil, err
 		}
+		password = string(b)
 	}
 
 	var (
-		jwk *jose.JSONWebKey
-		jwe *jose.JSONWebEncryption
+		jwk    *jose.JSONWebKey
+		jwe    *jose.JSONWebEncryption
+		rawKey []byte
 	)
-	if ctx.Bool("create") {
+	if create {
 		if ctx.IsSet("public-key") {
 			return nil, errs.IncompatibleFlag(ctx, "create", "public-key")
 		}
+		if ctx.Bool("raw-key") {
+			return nil, errs.IncompatibleFlag(ctx, "create", "raw-key")
+		}
 		if ctx.IsSet("private-key") {
 			return nil, errs.IncompatibleFlag(ctx, "create", "private-key")
 		}
@@ -508,21 +1015,29 @@ func createJWKDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 			return nil, errs.RequiredWithFlagValue(ctx, "create", "false", "public-key")
 		}
 		jwkFile := ctx.String("public-key")
-		jwk, err = jose.ParseKey(jwkFile)
-		if err != nil {
-			return nil, errs.FileError(err, jwkFile)
-		}
-
-		// Only use asymmetric cryptography
-		if _, ok := jwk.Key.([]byte); ok {
-			return nil, errors.New("invalid JWK: a symmetric key cannot be used as a provisioner")
-		}
-		// Create kid if not present
-		if jwk.KeyID == "" {
-			jwk.KeyID, err = jose.Thumbprint(jwk)
-			if err != nil {
+		if ctx.Bool("raw-key") {
+			if rawKey, err = readRawJWK(jwkFile); err != nil {
 				return nil, err
 			}
+		} else {
+			jwk, err = jose.ParseKey(jwkFile)
+			if err != nil {
+				return nil, errs.FileError(err, jwkFile)
+			}
+
+			// Only use asymmetric cryptography
+			if _, ok := jwk.Key.([]byte); ok {
+				return nil, errors.New("invalid JWK: a symmetric key cannot be used as a provisioner")
+			}
+			// Never store private key material as the public key
+			jwk = publicJWK(jwkFile, jwk)
+			// Create kid if not present
+			if jwk.KeyID == "" {
+				jwk.KeyID, err = jose.Thumbprint(jwk)
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		if ctx.IsSet("private-key") {
@@ -551,8 +1066,8 @@ func createJWKDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 
 				// Encrypt JWK
 				opts := []jose.Option{}
-				if ctx.IsSet("password-file") {
-					opts = append(opts, jose.WithPasswordFile(ctx.String("password-file")))
+				if password != "" {
+					opts = append(opts, jose.WithPassword([]byte(password)))
 				}
 				jwe, err = jose.EncryptJWK(privjwk, opts...)
 				if err != nil {
@@ -562,9 +1077,11 @@ func createJWKDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 		}
 	}
 
-	jwkPubBytes, err := jwk.MarshalJSON()
-	if err != nil {
-		return nil, errors.Wrap(err, "error marshaling JWK")
+	jwkPubBytes := rawKey
+	if jwkPubBytes == nil {
+		if jwkPubBytes, err = jwk.MarshalJSON(); err != nil {
+			return nil, errors.Wrap(err, "error marshaling JWK")
+		}
 	}
 	jwkProv := &linkedca.JWKProvisioner{
 		PublicKey: jwkPubBytes,
@@ -585,6 +1102,39 @@ func createJWKDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 	}, nil
 }
 
+// publicJWK returns the public key of the given JWK, read from filename. If the
+// JWK is a private key, it prints a warning to stderr.
+func publicJWK(filename string, jwk *jose.JSONWebKey) *jose.JSONWebKey {
+	if jwk.IsPublic() {
+		return jwk
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s contains a private key, only its public key will be added to the provisioner.\n", filename)
+	pub := jwk.Public()
+	return &pub
+}
+
+// readRawJWK reads a public JWK from the given file and returns its contents
+// unmodified. The JWK must be an asymmetric public key with a key id.
+func readRawJWK(filename string) ([]byte, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errs.FileError(err, filename)
+	}
+	var jwk jose.JSONWebKey
+	if err := json.Unmarshal(b, &jwk); err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s: invalid JWK", filename)
+	}
+	switch {
+	case !jwk.Valid():
+		return nil, errors.Errorf("error parsing %s: invalid JWK", filename)
+	case !jwk.IsPublic():
+		return nil, errors.Errorf("error parsing %s: a raw key must be a public key", filename)
+	case jwk.KeyID == "":
+		return nil, errors.Errorf("error parsing %s: a raw key must have a key id (kid)", filename)
+	}
+	return b, nil
+}
+
 func createACMEDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 	return &linkedca.ProvisionerDetails{
 		Data: &linkedca.ProvisionerDetails_ACME{
@@ -605,27 +1155,14 @@ func createSSHPOPDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error)
 }
 
 func createX5CDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
-	x5cRootFile := ctx.String("x5c-root")
-	if x5cRootFile == "" {
+	rootFiles := ctx.StringSlice("x5c-root")
+	if len(rootFiles) == 0 {
 		return nil, errs.RequiredWithFlagValue(ctx, "type", "x5c", "x5c-root")
 	}
 
-	roots, err := pemutil.ReadCertificateBundle(x5cRootFile)
+	rootBytes, err := readX5CRoots(rootFiles)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error loading X5C Root certificates from %s", x5cRootFile)
-	}
-	var rootBytes [][]byte
-	for _, r := range roots {
-		if r.KeyUsage&x509.KeyUsageCertSign == 0 {
-			return nil, errors.Errorf("error: certificate with common name '%s' cannot be "+
-				"used as an X5C root certificate.\n\n"+
-				"X5C provisioner root certificates must have the 'Certificate Sign' key "+
-				"usage extension.", r.Subject.CommonName)
-		}
-		rootBytes = append(rootBytes, pem.EncodeToMemory(&pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: r.Raw,
-		}))
+		return nil, err
 	}
 	return &linkedca.ProvisionerDetails{
 		Data: &linkedca.ProvisionerDetails_X5C{
@@ -637,12 +1174,12 @@ func createX5CDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 }
 
 func createNebulaDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
-	rootFile := ctx.String("nebula-root")
-	if rootFile == "" {
+	rootFiles := ctx.StringSlice("nebula-root")
+	if len(rootFiles) == 0 {
 		return nil, errs.RequiredWithFlagValue(ctx, "type", "nebula", "nebula-root")
 	}
 
-	rootBytes, err := readNebulaRoots(rootFile)
+	rootBytes, err := readNebulaRoots(rootFiles)
 	if err != nil {
 		return nil, err
 	}
@@ -657,45 +1194,17 @@ func createNebulaDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error)
 }
 
 func createK8SSADetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
-	pemKeysF := ctx.String("public-key")
-	if pemKeysF == "" {
-		return nil, errs.RequiredWithFlagValue(ctx, "type", "k8sSA", "public-key")
-	}
-
-	pemKeysB, err := os.ReadFile(pemKeysF)
+	keyFiles, err := k8sSAKeyFiles(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "error reading pem keys")
+		return nil, err
 	}
-
-	var (
-		block   *pem.Block
-		rest    = pemKeysB
-		pemKeys = []interface{}{}
-	)
-	for rest != nil {
-		block, rest = pem.Decode(rest)
-		if block == nil {
-			break
-		}
-		key, err := pemutil.ParseKey(pem.EncodeToMemory(block))
-		if err != nil {
-			return nil, errors.Wrapf(err, "error parsing public key from %s", pemKeysF)
-		}
-		switch q := key.(type) {
-		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
-		default:
-			return nil, errors.Errorf("Unexpected public key type %T in %s", q, pemKeysF)
-		}
-		pemKeys = append(pemKeys, key)
+	if len(keyFiles) == 0 {
+		return nil, errs.RequiredWithFlagValue(ctx, "type", "k8sSA", "pem-keys")
 	}
 
-	var pubKeyBytes [][]byte
-	for _, k := range pemKeys {
-		blk, err := pemutil.Serialize(k)
-		if err != nil {
-			return nil, errors.Wrap(err, "error serializing pem key")
-		}
-		pubKeyBytes = append(pubKeyBytes, pem.EncodeToMemory(blk))
+	pubKeyBytes, err := readK8SSAPublicKeys(keyFiles)
+	if err != nil {
+		return nil, err
 	}
 	return &linkedca.ProvisionerDetails{
 		Data: &linkedca.ProvisionerDetails_K8SSA{
@@ -716,9 +1225,11 @@ func createOIDCDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 	if confURL == "" {
 		return nil, errs.RequiredWithFlagValue(ctx, "type", ctx.String("type"), "configuration-endpoint")
 	}
-	u, err := url.Parse(confURL)
-	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
-		return nil, errs.InvalidFlagValue(ctx, "configuration-endpoint", confURL, "")
+	if err := checkConfigurationEndpoint(ctx, confURL); err != nil {
+		return nil, err
+	}
+	if err := validateOIDCConfiguration(ctx, confURL, ctx.String("tenant-id")); err != nil {
+		return nil, errs.InvalidFlagValueMsg(ctx, "configuration-endpoint", confURL, err.Error())
 	}
 
 	return &linkedca.ProvisionerDetails{
@@ -737,6 +1248,126 @@ func createOIDCDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 	}, nil
 }
 
+// checkConfigurationEndpoint checks that the OpenID Connect configuration
+// endpoint is an https URL. Plain http URLs are only allowed with the
+// --insecure flag.
+func checkConfigurationEndpoint(ctx *cli.Context, confURL string) error {
+	u, err := url.Parse(confURL)
+	switch {
+	case err != nil || (u.Scheme != "https" && u.Scheme != "http"):
+		return errs.InvalidFlagValue(ctx, "configuration-endpoint", confURL, "")
+	case u.Scheme == "http" && !ctx.Bool("insecure"):
+		return errs.InvalidFlagValueMsg(ctx, "configuration-endpoint", confURL, "http URLs require the '--insecure' flag")
+	default:
+		return nil
+	}
+}
+
+// oidcDiscoveryTimeout is the maximum time used to retrieve the OpenID Connect
+// discovery document if --timeout is not set.
+const oidcDiscoveryTimeout = 5 * time.Second
+
+// validateOIDCConfiguration retrieves the OpenID Connect discovery document in
+// the given url and checks that it contains the fields required by the CA. The
+// document is written to the file in --configuration-snapshot if set.
+func validateOIDCConfiguration(ctx *cli.Context, confURL, tenantID string) error {
+	if tenantID != "" {
+		confURL = strings.ReplaceAll(confURL, "{tenantid}", tenantID)
+	}
+	client, err := oidcDiscoveryClient(ctx)
+	if err != nil {
+		return err
+	}
+	timeout := oidcDiscoveryTimeout
+	if ctx.IsSet("timeout") && ctx.Duration("timeout") > 0 {
+		timeout = ctx.Duration("timeout")
+	}
+	reqCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, confURL, http.NoBody)
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving %s", confURL)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return oidcDiscoveryError(reqCtx, err, confURL, timeout)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return errors.Errorf("error retrieving %s: status code %d", confURL, resp.StatusCode)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return oidcDiscoveryError(reqCtx, err, confURL, timeout)
+	}
+
+	var conf struct {
+		Issuer                string `json:"issuer"`
+		JWKSUri               string `json:"jwks_uri"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+	}
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return errors.Wrapf(err, "error reading %s: unsupported format", confURL)
+	}
+	switch {
+	case conf.Issuer == "":
+		return errors.Errorf("%s does not contain the issuer", confURL)
+	case conf.JWKSUri == "":
+		return errors.Errorf("%s does not contain the jwks_uri", confURL)
+	case conf.AuthorizationEndpoint == "":
+		return errors.Errorf("%s does not contain the authorization_endpoint", confURL)
+	}
+
+	if filename := ctx.String("configuration-snapshot"); filename != "" {
+		if err := utils.WriteFile(filename, b, 0600); err != nil {
+			return errs.FileError(err, filename)
+		}
+	}
+	return nil
+}
+
+// oidcDiscoveryError returns the error retrieving the OpenID Connect discovery
+// document, wrapping context.DeadlineExceeded if the request timed out.
+func oidcDiscoveryError(reqCtx context.Context, err error, confURL string, timeout time.Duration) error {
+	if errors.Is(reqCtx.Err(), context.DeadlineExceeded) {
+		return errors.Wrapf(context.DeadlineExceeded, "error retrieving %s: timed out after %s", confURL, timeout)
+	}
+	return errors.Wrapf(err, "error retrieving %s", confURL)
+}
+
+// oidcDiscoveryClient returns the http client used to retrieve the OpenID
+// Connect discovery document. It trusts the system roots and the CA root, so
+// identity providers with a certificate issued by the CA can be used.
+func oidcDiscoveryClient(ctx *cli.Context) (*http.Client, error) {
+	root := ctx.String("root")
+	if root == "" {
+		if _, err := os.Stat(pki.GetRootCAPath()); err == nil {
+			root = pki.GetRootCAPath()
+		}
+	}
+	if root == "" {
+		return &http.Client{}, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	b, err := os.ReadFile(root)
+	if err != nil {
+		return nil, errs.FileError(err, root)
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.Errorf("error reading %s: no certificates found", root)
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+	return &http.Client{Transport: tr}, nil
+}
+
 func createAWSDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 	d, err := parseInstanceAge(ctx)
 	if err != nil {
@@ -762,11 +1393,16 @@ func createAzureDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error)
 	if tenantID == "" {
 		return nil, errs.RequiredWithFlagValue(ctx, "type", ctx.String("type"), "azure-tenant")
 	}
+	audience, err := parseAzureAudience(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	return &linkedca.ProvisionerDetails{
 		Data: &linkedca.ProvisionerDetails_Azure{
 			Azure: &linkedca.AzureProvisioner{
 				TenantId:               tenantID,
+				Audience:               audience,
 				ResourceGroups:         ctx.StringSlice("azure-resource-group"),
 				SubscriptionIds:        ctx.StringSlice("azure-subscription-id"),
 				ObjectIds:              ctx.StringSlice("azure-object-id"),
@@ -797,15 +1433,24 @@ func createGCPDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
 }
 
 func createSCEPDetails(ctx *cli.Context) (*linkedca.ProvisionerDetails, error) {
+	alg, err := parseSCEPEncryptionAlgorithm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	minPublicKeyLength, err := parseSCEPMinimumPublicKeyLength(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return &linkedca.ProvisionerDetails{
 		Data: &linkedca.ProvisionerDetails_SCEP{
 			SCEP: &linkedca.SCEPProvisioner{
 				ForceCn:                       ctx.Bool("force-cn"),
 				Challenge:                     ctx.String("challenge"),
 				Capabilities:                  ctx.StringSlice("capabilities"),
-				MinimumPublicKeyLength:        int32(ctx.Int("min-public-key-length")),
+				MinimumPublicKeyLength:        minPublicKeyLength,
 				IncludeRoot:                   ctx.Bool("include-root"),
-				EncryptionAlgorithmIdentifier: int32(ctx.Int("encryption-algorithm-identifier")),
+				EncryptionAlgorithmIdentifier: alg,
 			},
 		},
 	}, nil
@@ -0,0 +1,390 @@
+package provisionerbeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	adminAPI "github.com/smallstep/certificates/authority/admin/api"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.step.sm/linkedca"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestCreateJWKDetails_privateKey(t *testing.T) {
+	jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	require.NoError(t, err)
+	b, err := json.Marshal(jwk)
+	require.NoError(t, err)
+	filename := filepath.Join(t.TempDir(), "private.jwk")
+	require.NoError(t, os.WriteFile(filename, b, 0600))
+
+	ctx := newTestContext(t, addCommand().Flags, []string{"--public-key", filename})
+	details, err := createJWKDetails(ctx)
+	require.NoError(t, err)
+
+	data, ok := details.GetData().(*linkedca.ProvisionerDetails_JWK)
+	require.True(t, ok)
+	var got jose.JSONWebKey
+	require.NoError(t, json.Unmarshal(data.JWK.PublicKey, &got))
+	assert.True(t, got.IsPublic())
+	assert.NotContains(t, string(data.JWK.PublicKey), `"d":`)
+	assert.Empty(t, data.JWK.EncryptedPrivateKey)
+
+	thumbprint, err := jose.Thumbprint(jwk)
+	require.NoError(t, err)
+	assert.Equal(t, thumbprint, got.KeyID)
+}
+
+func TestCreateJWKDetails_rawKey(t *testing.T) {
+	jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	writeFile := func(name string, b []byte) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, b, 0600))
+		return filename
+	}
+
+	// Non-canonical encoding with a key id set by an external tool.
+	pub := jwk.Public()
+	pub.KeyID = "external-kid"
+	b, err := json.MarshalIndent(pub, "", "\t")
+	require.NoError(t, err)
+	raw := append(b, '\n')
+
+	noKid, err := json.Marshal(jwk.Public())
+	require.NoError(t, err)
+	jwk.KeyID = "external-kid"
+	private, err := json.Marshal(jwk)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr bool
+	}{
+		{"ok", raw, false},
+		{"fail no kid", noKid, true},
+		{"fail private", private, true},
+		{"fail not JSON", []byte("not a jwk"), true},
+		{"fail not JWK", []byte(`{"kty":"EC"}`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeFile(tt.name+".jwk", tt.content)
+			ctx := newTestContext(t, addCommand().Flags, []string{"--public-key", filename, "--raw-key"})
+			details, err := createJWKDetails(ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, details.GetJWK().PublicKey)
+		})
+	}
+
+	t.Run("fail with create", func(t *testing.T) {
+		ctx := newTestContext(t, addCommand().Flags, []string{"--create", "--raw-key"})
+		_, err := createJWKDetails(ctx)
+		assert.Error(t, err)
+	})
+}
+
+func TestExtendProvisioner(t *testing.T) {
+	base := &linkedca.Provisioner{
+		Name:         "acme-team-a",
+		Type:         linkedca.Provisioner_ACME,
+		X509Template: &linkedca.Template{Template: []byte("x509"), Data: []byte(`{"foo":"bar"}`)},
+		SshTemplate:  &linkedca.Template{Template: []byte("ssh")},
+		Claims: &linkedca.Claims{
+			X509: &linkedca.X509Claims{Enabled: true, Durations: &linkedca.Durations{Min: "5m", Max: "24h"}},
+			Ssh:  &linkedca.SSHClaims{Enabled: true},
+		},
+	}
+
+	p := &linkedca.Provisioner{Name: "acme-team-b"}
+	ctx := newTestContext(t, addCommand().Flags, []string{"--x509-max-dur", "48h", "--ssh=false"})
+	require.NoError(t, extendProvisioner(ctx, p, base))
+
+	assert.Equal(t, "acme-team-b", p.Name)
+	assert.Equal(t, []byte("x509"), p.X509Template.Template)
+	assert.Equal(t, []byte(`{"foo":"bar"}`), p.X509Template.Data)
+	assert.Equal(t, []byte("ssh"), p.SshTemplate.Template)
+	assert.Equal(t, "5m", p.Claims.X509.Durations.Min)
+	assert.Equal(t, "48h", p.Claims.X509.Durations.Max)
+	assert.False(t, p.Claims.Ssh.Enabled)
+
+	// The base provisioner is not modified.
+	assert.Equal(t, "24h", base.Claims.X509.Durations.Max)
+	assert.True(t, base.Claims.Ssh.Enabled)
+
+	ctx = newTestContext(t, addCommand().Flags, []string{"--x509-default-dur", "1m"})
+	assert.Error(t, extendProvisioner(ctx, &linkedca.Provisioner{Name: "invalid"}, base))
+}
+
+func TestValidateOIDCConfiguration(t *testing.T) {
+	documents := map[string]string{
+		"/ok":               `{"issuer":"https://example.com","jwks_uri":"https://example.com/jwks","authorization_endpoint":"https://example.com/auth"}`,
+		"/tenant/tid":       `{"issuer":"https://example.com","jwks_uri":"https://example.com/jwks","authorization_endpoint":"https://example.com/auth"}`,
+		"/no-issuer":        `{"jwks_uri":"https://example.com/jwks","authorization_endpoint":"https://example.com/auth"}`,
+		"/no-jwks":          `{"issuer":"https://example.com","authorization_endpoint":"https://example.com/auth"}`,
+		"/no-authorization": `{"issuer":"https://example.com","jwks_uri":"https://example.com/jwks"}`,
+		"/bad-json":         `not json`,
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := documents[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.crt")
+	require.NoError(t, os.WriteFile(root, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: srv.Certificate().Raw,
+	}), 0600))
+	snapshot := filepath.Join(dir, "snapshot.json")
+
+	tests := []struct {
+		name     string
+		path     string
+		tenantID string
+		wantErr  string
+	}{
+		{"ok", "/ok", "", ""},
+		{"ok tenant", "/tenant/{tenantid}", "tid", ""},
+		{"fail issuer", "/no-issuer", "", "does not contain the issuer"},
+		{"fail jwks_uri", "/no-jwks", "", "does not contain the jwks_uri"},
+		{"fail authorization_endpoint", "/no-authorization", "", "does not contain the authorization_endpoint"},
+		{"fail json", "/bad-json", "", "unsupported format"},
+		{"fail not found", "/missing", "", "status code 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(snapshot)
+			ctx := newTestContext(t, addCommand().Flags, []string{"--root", root, "--configuration-snapshot", snapshot})
+			err := validateOIDCConfiguration(ctx, srv.URL+tt.path, tt.tenantID)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.NoFileExists(t, snapshot)
+				return
+			}
+			require.NoError(t, err)
+			b, err := os.ReadFile(snapshot)
+			require.NoError(t, err)
+			assert.JSONEq(t, documents["/ok"], string(b))
+		})
+	}
+
+	t.Run("fail untrusted", func(t *testing.T) {
+		other := filepath.Join(dir, "other.crt")
+		require.NoError(t, os.WriteFile(other, mustX509Certificate(t, "Other Root", true), 0600))
+		ctx := newTestContext(t, addCommand().Flags, []string{"--root", other})
+		assert.Error(t, validateOIDCConfiguration(ctx, srv.URL+"/ok", ""))
+	})
+}
+
+func TestCheckConfigurationEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		args    []string
+		wantErr bool
+	}{
+		{"ok https", "https://example.com/.well-known/openid-configuration", nil, false},
+		{"ok http insecure", "http://example.com/.well-known/openid-configuration", []string{"--insecure"}, false},
+		{"fail http", "http://example.com/.well-known/openid-configuration", nil, true},
+		{"fail scheme", "ftp://example.com/.well-known/openid-configuration", []string{"--insecure"}, true},
+		{"fail path", "example.com/.well-known/openid-configuration", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, addCommand().Flags, tt.args)
+			err := checkConfigurationEndpoint(ctx, tt.url)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// stubCreator is a provisionerCreator returning the given errors on each call
+// to CreateProvisioner.
+type stubCreator struct {
+	createErrs []error
+	creates    int
+	removes    int
+}
+
+func (c *stubCreator) CreateProvisioner(prov *linkedca.Provisioner) (*linkedca.Provisioner, error) {
+	err := c.createErrs[c.creates]
+	c.creates++
+	if err != nil {
+		return nil, err
+	}
+	return prov, nil
+}
+
+func (c *stubCreator) RemoveProvisioner(opts ...ca.ProvisionerOption) error {
+	c.removes++
+	return nil
+}
+
+func TestCreateProvisioner(t *testing.T) {
+	conflict := &ca.AdminClientError{
+		Type:    "badRequest",
+		Message: "error storing provisioner cicd: provisioner with name cicd already exists",
+	}
+	other := &ca.AdminClientError{
+		Type:    "badRequest",
+		Message: "provisioner with token ID foo already exists",
+	}
+	tests := []struct {
+		name        string
+		createErrs  []error
+		force       bool
+		wantErr     error
+		wantCreates int
+		wantRemoves int
+		wantWarning bool
+	}{
+		{"ok", []error{nil}, false, nil, 1, 0, false},
+		{"conflict", []error{conflict}, false, conflict, 1, 0, false},
+		{"conflict force", []error{conflict, nil}, true, nil, 2, 1, true},
+		{"other error force", []error{other}, true, other, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &stubCreator{createErrs: tt.createErrs}
+			var buf bytes.Buffer
+			p, err := createProvisioner(client, &linkedca.Provisioner{Name: "cicd"}, tt.force, &buf)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, p)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "cicd", p.Name)
+			}
+			assert.Equal(t, tt.wantCreates, client.creates)
+			assert.Equal(t, tt.wantRemoves, client.removes)
+			assert.Equal(t, tt.wantWarning, strings.Contains(buf.String(), "WARNING"))
+		})
+	}
+}
+
+// stubEABCreator is an eabKeyCreator returning the given error.
+type stubEABCreator struct {
+	err   error
+	names []string
+}
+
+func (c *stubEABCreator) CreateExternalAccountKey(provisionerName string, eakRequest *adminAPI.CreateExternalAccountKeyRequest) (*linkedca.EABKey, error) {
+	c.names = append(c.names, provisionerName)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &linkedca.EABKey{Id: "key-id", Provisioner: provisionerName, HmacKey: []byte{0xfb, 0xff}}, nil
+}
+
+func TestPrintEABFollowUp(t *testing.T) {
+	acme := func(requireEAB bool) *linkedca.Provisioner {
+		return &linkedca.Provisioner{
+			Type: linkedca.Provisioner_ACME,
+			Name: "acme",
+			Details: &linkedca.ProvisionerDetails{
+				Data: &linkedca.ProvisionerDetails_ACME{
+					ACME: &linkedca.ACMEProvisioner{RequireEab: requireEAB},
+				},
+			},
+		}
+	}
+
+	t.Run("no eab", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := &stubEABCreator{}
+		require.NoError(t, printEABFollowUp(client, acme(false), true, &buf))
+		assert.Empty(t, buf.String())
+		assert.Empty(t, client.names)
+	})
+
+	t.Run("hint", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := &stubEABCreator{}
+		require.NoError(t, printEABFollowUp(client, acme(true), false, &buf))
+		assert.Contains(t, buf.String(), "step beta ca acme eab add acme")
+		assert.Empty(t, client.names)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := &stubEABCreator{}
+		require.NoError(t, printEABFollowUp(client, acme(true), true, &buf))
+		assert.Equal(t, []string{"acme"}, client.names)
+		assert.Contains(t, buf.String(), "Key ID: key-id")
+		assert.Contains(t, buf.String(), "Key (base64, raw url encoded): -_8")
+	})
+
+	t.Run("create error", func(t *testing.T) {
+		var buf bytes.Buffer
+		client := &stubEABCreator{err: errors.New("not implemented")}
+		err := printEABFollowUp(client, acme(true), true, &buf)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "provisioner acme was created")
+	})
+}
+
+func TestAddFromDirAction_dryRun(t *testing.T) {
+	dir := t.TempDir()
+	b, err := protojson.Marshal(&linkedca.Provisioner{
+		Type: linkedca.Provisioner_ACME,
+		Name: "acme",
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_ACME{ACME: &linkedca.ACMEProvisioner{}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "acme.json"), b, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`{"name":"invalid"}`), 0600))
+
+	// Without a CA the command only fails if it tries to create the
+	// provisioners, or because of the invalid file.
+	ctx := newTestContext(t, addCommand().Flags, []string{"--dry-run"})
+	err = addFromDirAction(ctx, dir)
+	require.Error(t, err)
+	assert.Equal(t, "error creating 1 provisioners from "+dir, err.Error())
+}
+
+func TestAddAction_bulkFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"from-dir and from-ca-config", []string{"--from-dir", "dir", "--from-ca-config", "ca.json"}, "mutually exclusive"},
+		{"force and from-ca-config", []string{"--from-ca-config", "ca.json", "--force"}, "--force"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, addCommand().Flags, tt.args)
+			err := addAction(ctx)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
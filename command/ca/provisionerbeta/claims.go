@@ -0,0 +1,270 @@
+package provisionerbeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/config"
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+)
+
+var claimsJSONFlag = cli.StringFlag{
+	Name: "claims-json",
+	Usage: `The <file> containing a JSON object with the provisioner claims, using the
+same keys as the claims in the CA configuration (e.g. "minTLSCertDuration",
+"maxTLSCertDuration", "defaultTLSCertDuration", "disableRenewal").
+Files with a ".yaml" or ".yml" extension are read as YAML. Claims set with an
+explicit flag take precedence over the ones in the file.`,
+}
+
+var maxDurFlag = cli.StringFlag{
+	Name: "max-dur",
+	Usage: `The maximum <duration> for all the certificates generated by this provisioner,
+setting **--x509-max-dur**, **--ssh-user-max-dur** and **--ssh-host-max-dur**
+together. It is only applied to the enabled certificate types, see **--x509**
+and **--ssh**. The specific flags take precedence over **--max-dur**, and
+**--max-dur** takes precedence over **--claims-json**.`,
+}
+
+// Hidden flags named after the claims in the CA configuration, they are
+// deprecated aliases of the x509 duration flags.
+var (
+	minTLSCertDurationFlag = cli.StringFlag{
+		Name:   "min-tls-cert-duration",
+		Usage:  `Deprecated, use **--x509-min-dur**.`,
+		Hidden: true,
+	}
+	maxTLSCertDurationFlag = cli.StringFlag{
+		Name:   "max-tls-cert-duration",
+		Usage:  `Deprecated, use **--x509-max-dur**.`,
+		Hidden: true,
+	}
+	defaultTLSCertDurationFlag = cli.StringFlag{
+		Name:   "default-tls-cert-duration",
+		Usage:  `Deprecated, use **--x509-default-dur**.`,
+		Hidden: true,
+	}
+)
+
+// deprecatedDurFlags maps the deprecated duration flags to the flags that
+// replace them.
+var deprecatedDurFlags = []struct {
+	name, canonical string
+}{
+	{"min-tls-cert-duration", "x509-min-dur"},
+	{"max-tls-cert-duration", "x509-max-dur"},
+	{"default-tls-cert-duration", "x509-default-dur"},
+}
+
+// applyDeprecatedDurFlags sets the x509 duration flags using the value of the
+// deprecated flags, printing a deprecation warning to w.
+func applyDeprecatedDurFlags(ctx *cli.Context, w io.Writer) error {
+	for _, f := range deprecatedDurFlags {
+		if !ctx.IsSet(f.name) {
+			continue
+		}
+		if ctx.IsSet(f.canonical) {
+			return errs.IncompatibleFlagWithFlag(ctx, f.name, f.canonical)
+		}
+		fmt.Fprintf(w, "Warning: --%s is deprecated and will be removed in a future release, use --%s instead.\n", f.name, f.canonical)
+		if err := ctx.Set(f.canonical, ctx.String(f.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimsJSON is the JSON representation of the provisioner claims in the CA
+// configuration. Pointers are used to distinguish between absent and zero
+// values.
+type claimsJSON struct {
+	MinTLSDur               *string `json:"minTLSCertDuration"`
+	MaxTLSDur               *string `json:"maxTLSCertDuration"`
+	DefaultTLSDur           *string `json:"defaultTLSCertDuration"`
+	MinUserSSHDur           *string `json:"minUserSSHCertDuration"`
+	MaxUserSSHDur           *string `json:"maxUserSSHCertDuration"`
+	DefaultUserSSHDur       *string `json:"defaultUserSSHCertDuration"`
+	MinHostSSHDur           *string `json:"minHostSSHCertDuration"`
+	MaxHostSSHDur           *string `json:"maxHostSSHCertDuration"`
+	DefaultHostSSHDur       *string `json:"defaultHostSSHCertDuration"`
+	EnableSSHCA             *bool   `json:"enableSSHCA"`
+	DisableRenewal          *bool   `json:"disableRenewal"`
+	AllowRenewalAfterExpiry *bool   `json:"allowRenewalAfterExpiry"`
+}
+
+// readClaimsJSON reads and validates the claims in the given file.
+func readClaimsJSON(filename string) (*claimsJSON, error) {
+	b, err := readJSONFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(claimsJSON)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", filename)
+	}
+
+	for key, value := range map[string]*string{
+		"minTLSCertDuration":         c.MinTLSDur,
+		"maxTLSCertDuration":         c.MaxTLSDur,
+		"defaultTLSCertDuration":     c.DefaultTLSDur,
+		"minUserSSHCertDuration":     c.MinUserSSHDur,
+		"maxUserSSHCertDuration":     c.MaxUserSSHDur,
+		"defaultUserSSHCertDuration": c.DefaultUserSSHDur,
+		"minHostSSHCertDuration":     c.MinHostSSHDur,
+		"maxHostSSHCertDuration":     c.MaxHostSSHDur,
+		"defaultHostSSHCertDuration": c.DefaultHostSSHDur,
+	} {
+		if value == nil {
+			continue
+		}
+		if _, err := time.ParseDuration(*value); err != nil {
+			return nil, errors.Wrapf(err, "error parsing %s: invalid value '%s' for %s", filename, *value, key)
+		}
+	}
+
+	return c, nil
+}
+
+// applyMaxDur sets the maximum durations of the enabled certificate types to
+// the value of the --max-dur flag. Durations set with a specific flag are not
+// modified.
+func applyMaxDur(ctx *cli.Context, claims *linkedca.Claims) error {
+	if !ctx.IsSet("max-dur") {
+		return nil
+	}
+	value := ctx.String("max-dur")
+	if _, err := parseDuration(ctx, "max-dur", value); err != nil {
+		return err
+	}
+
+	set := func(flag string, d **linkedca.Durations) {
+		if ctx.IsSet(flag) {
+			return
+		}
+		if *d == nil {
+			*d = &linkedca.Durations{}
+		}
+		(*d).Max = value
+	}
+	if claims.X509 != nil && claims.X509.Enabled {
+		set("x509-max-dur", &claims.X509.Durations)
+	}
+	if claims.Ssh != nil && claims.Ssh.Enabled {
+		set("ssh-user-max-dur", &claims.Ssh.UserDurations)
+		set("ssh-host-max-dur", &claims.Ssh.HostDurations)
+	}
+	return nil
+}
+
+// applyClaimsJSON sets the claims in the file passed with the --claims-json
+// flag on the given claims. Claims set with an explicit flag are not modified.
+func applyClaimsJSON(ctx *cli.Context, claims *linkedca.Claims) error {
+	filename := ctx.String("claims-json")
+	if filename == "" {
+		return nil
+	}
+
+	c, err := readClaimsJSON(filename)
+	if err != nil {
+		return err
+	}
+
+	setString := func(flag string, dst *string, src *string) {
+		if src != nil && !ctx.IsSet(flag) {
+			*dst = *src
+		}
+	}
+	setBool := func(flag string, dst *bool, src *bool) {
+		if src != nil && !ctx.IsSet(flag) {
+			*dst = *src
+		}
+	}
+
+	if claims.X509 == nil {
+		claims.X509 = &linkedca.X509Claims{Enabled: true}
+	}
+	if claims.X509.Durations == nil {
+		claims.X509.Durations = &linkedca.Durations{}
+	}
+	if claims.Ssh == nil {
+		claims.Ssh = &linkedca.SSHClaims{}
+	}
+	if claims.Ssh.UserDurations == nil {
+		claims.Ssh.UserDurations = &linkedca.Durations{}
+	}
+	if claims.Ssh.HostDurations == nil {
+		claims.Ssh.HostDurations = &linkedca.Durations{}
+	}
+
+	d := claims.X509.Durations
+	setString("x509-min-dur", &d.Min, c.MinTLSDur)
+	setString("x509-max-dur", &d.Max, c.MaxTLSDur)
+	setString("x509-default-dur", &d.Default, c.DefaultTLSDur)
+	d = claims.Ssh.UserDurations
+	setString("ssh-user-min-dur", &d.Min, c.MinUserSSHDur)
+	setString("ssh-user-max-dur", &d.Max, c.MaxUserSSHDur)
+	setString("ssh-user-default-dur", &d.Default, c.DefaultUserSSHDur)
+	d = claims.Ssh.HostDurations
+	setString("ssh-host-min-dur", &d.Min, c.MinHostSSHDur)
+	setString("ssh-host-max-dur", &d.Max, c.MaxHostSSHDur)
+	setString("ssh-host-default-dur", &d.Default, c.DefaultHostSSHDur)
+	if !ctx.IsSet("enable-ssh-ca") {
+		setBool("ssh", &claims.Ssh.Enabled, c.EnableSSHCA)
+	}
+	setBool("disable-renewal", &claims.DisableRenewal, c.DisableRenewal)
+	setBool("allow-renewal-after-expiry", &claims.AllowRenewalAfterExpiry, c.AllowRenewalAfterExpiry)
+
+	return nil
+}
+
+// permissiveClaimsWarnings returns a warning for each maximum duration in the
+// given provisioner claims that is longer than the maximum duration in the
+// global claims of the CA. The global claims not set use the defaults of the
+// CA.
+func permissiveClaimsWarnings(c *linkedca.Claims, global *provisioner.Claims) []string {
+	if global == nil {
+		global = &provisioner.Claims{}
+	}
+	globalMax := func(d, def *provisioner.Duration) time.Duration {
+		if d != nil {
+			return d.Duration
+		}
+		return def.Duration
+	}
+
+	defaults := config.GlobalProvisionerClaims
+	checks := []struct {
+		name  string
+		value string
+		max   time.Duration
+	}{
+		{"x509", c.GetX509().GetDurations().GetMax(), globalMax(global.MaxTLSDur, defaults.MaxTLSDur)},
+		{"ssh user", c.GetSsh().GetUserDurations().GetMax(), globalMax(global.MaxUserSSHDur, defaults.MaxUserSSHDur)},
+		{"ssh host", c.GetSsh().GetHostDurations().GetMax(), globalMax(global.MaxHostSSHDur, defaults.MaxHostSSHDur)},
+	}
+
+	var warnings []string
+	for _, check := range checks {
+		if check.value == "" {
+			continue
+		}
+		// Invalid durations are reported when the provisioner is created.
+		d, err := time.ParseDuration(check.value)
+		if err != nil || d <= check.max {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("the maximum %s certificate duration %s is longer than the global maximum of the CA %s",
+			check.name, check.value, check.max))
+	}
+	return warnings
+}
@@ -0,0 +1,46 @@
+package provisionerbeta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/stretchr/testify/assert"
+	"go.step.sm/linkedca"
+)
+
+func TestPermissiveClaimsWarnings(t *testing.T) {
+	claims := func(x509Max, userMax, hostMax string) *linkedca.Claims {
+		return &linkedca.Claims{
+			X509: &linkedca.X509Claims{Durations: &linkedca.Durations{Max: x509Max}},
+			Ssh: &linkedca.SSHClaims{
+				UserDurations: &linkedca.Durations{Max: userMax},
+				HostDurations: &linkedca.Durations{Max: hostMax},
+			},
+		}
+	}
+	global := &provisioner.Claims{
+		MaxTLSDur: &provisioner.Duration{Duration: 720 * time.Hour},
+	}
+
+	tests := []struct {
+		name   string
+		claims *linkedca.Claims
+		global *provisioner.Claims
+		want   int
+	}{
+		{"no claims", nil, global, 0},
+		{"no durations", &linkedca.Claims{}, global, 0},
+		{"within global", claims("720h", "", ""), global, 0},
+		{"x509 above global", claims("8760h", "", ""), global, 1},
+		{"x509 above default", claims("48h", "", ""), nil, 1},
+		{"ssh above default", claims("", "48h", "2000h"), nil, 2},
+		{"ssh within default", claims("", "24h", "720h"), nil, 0},
+		{"invalid duration", claims("foo", "", ""), global, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Len(t, permissiveClaimsWarnings(tt.claims, tt.global), tt.want)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package provisionerbeta
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+	"google.golang.org/protobuf/proto"
+)
+
+func cloneCommand() cli.Command {
+	return cli.Command{
+		Name:   "clone",
+		Action: cli.ActionFunc(cloneAction),
+		Usage:  "create a copy of a provisioner with a new name",
+		UsageText: `**step beta ca provisioner clone** <source> <name>
+[**--public-key**=<file>] [**--private-key**=<file>] [**--dry-run**]
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name: "public-key",
+				Usage: `The <file> containing the JWK public key of the new provisioner. By default
+a new key pair is generated when a JWK provisioner is cloned.`,
+			},
+			cli.StringFlag{
+				Name:  "private-key",
+				Usage: `The <file> containing the JWK private key of the new provisioner.`,
+			},
+			dryRunFlag,
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step beta ca provisioner clone** creates a new provisioner with all the
+configuration of an existing one: the type specific configuration, claims
+and templates.
+
+Unlike **step beta ca provisioner add --extends**, the type specific
+configuration is also copied. The keys of JWK provisioners are never copied,
+a new key pair is generated, or the one in **--public-key** and
+**--private-key** is used. A warning is printed for the configuration that
+cannot be copied or that is shared by both provisioners.
+
+## POSITIONAL ARGUMENTS
+
+<source>
+: The name of the provisioner to copy.
+
+<name>
+: The name of the new provisioner.
+
+## EXAMPLES
+
+Create a copy of the ACME provisioner:
+'''
+$ step beta ca provisioner clone acme acme-staging
+'''
+
+Create a copy of a JWK provisioner using an existing key:
+'''
+$ step beta ca provisioner clone ci ci-2 --public-key ci-2.pub.json --private-key ci-2.json
+'''
+
+Print the new provisioner without creating it:
+'''
+$ step beta ca provisioner clone acme acme-staging --dry-run
+'''`,
+	}
+}
+
+func cloneAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 2); err != nil {
+		return err
+	}
+	if ctx.IsSet("private-key") && !ctx.IsSet("public-key") {
+		return errs.RequiredWithFlag(ctx, "private-key", "public-key")
+	}
+
+	args := ctx.Args()
+	srcName, name := args.Get(0), args.Get(1)
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+	src, err := client.GetProvisioner(ca.WithProvisionerName(srcName))
+	if err != nil {
+		return notFoundExitError(err)
+	}
+	if src.Type != linkedca.Provisioner_JWK && (ctx.IsSet("public-key") || ctx.IsSet("private-key")) {
+		return errs.InvalidFlagValueMsg(ctx, "public-key", ctx.String("public-key"),
+			fmt.Sprintf("provisioner %s is not a JWK provisioner", srcName))
+	}
+
+	p, warnings := cloneProvisioner(src, name)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s.\n", w)
+	}
+	if p.Type == linkedca.Provisioner_JWK {
+		if p.Details, err = newJWKDetails(ctx, !ctx.IsSet("public-key")); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("dry-run") {
+		if err := printBackend(ctx, os.Stderr); err != nil {
+			return err
+		}
+		return printProvisioner(p)
+	}
+	if p, err = client.CreateProvisioner(p); err != nil {
+		return err
+	}
+	return printProvisioner(p)
+}
+
+// cloneProvisioner returns a copy of the given provisioner with a new name
+// and without its identifiers, and the warnings about the configuration that
+// is not copied, or that is shared by both provisioners. The details of JWK
+// provisioners must be set by the caller.
+func cloneProvisioner(src *linkedca.Provisioner, name string) (*linkedca.Provisioner, []string) {
+	p := proto.Clone(src).(*linkedca.Provisioner)
+	p.Id = ""
+	p.AuthorityId = ""
+	p.Name = name
+	p.CreatedAt = nil
+	p.DeletedAt = nil
+
+	var warnings []string
+	switch d := p.Details.GetData().(type) {
+	case *linkedca.ProvisionerDetails_JWK:
+		p.Details = nil
+		warnings = append(warnings, "the key and encrypted key of the JWK provisioner are not copied, the new provisioner uses a different key")
+	case *linkedca.ProvisionerDetails_ACME:
+		if d.ACME.RequireEab {
+			warnings = append(warnings, "the external account binding keys are not copied, new keys must be created for the new provisioner")
+		}
+	case *linkedca.ProvisionerDetails_OIDC:
+		warnings = append(warnings, "the OIDC client id and secret are copied, both provisioners use the same client")
+	case *linkedca.ProvisionerDetails_SCEP:
+		if d.SCEP.Challenge != "" {
+			warnings = append(warnings, "the SCEP challenge is copied, both provisioners use the same challenge")
+		}
+	}
+	return p, warnings
+}
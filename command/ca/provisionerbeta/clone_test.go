@@ -0,0 +1,48 @@
+package provisionerbeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.step.sm/linkedca"
+)
+
+func TestCloneProvisioner(t *testing.T) {
+	src := &linkedca.Provisioner{
+		Id:          "id",
+		AuthorityId: "authority",
+		Type:        linkedca.Provisioner_ACME,
+		Name:        "acme",
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_ACME{
+				ACME: &linkedca.ACMEProvisioner{ForceCn: true, RequireEab: true},
+			},
+		},
+		Claims: &linkedca.Claims{DisableRenewal: true},
+	}
+
+	p, warnings := cloneProvisioner(src, "acme-staging")
+	assert.Equal(t, "", p.Id)
+	assert.Equal(t, "", p.AuthorityId)
+	assert.Equal(t, "acme-staging", p.Name)
+	assert.True(t, p.Claims.DisableRenewal)
+	assert.True(t, p.Details.GetACME().ForceCn)
+	assert.Len(t, warnings, 1)
+	// The source is not modified.
+	assert.Equal(t, "acme", src.Name)
+	assert.Equal(t, "id", src.Id)
+
+	jwk := &linkedca.Provisioner{
+		Type: linkedca.Provisioner_JWK,
+		Name: "jwk",
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_JWK{
+				JWK: &linkedca.JWKProvisioner{PublicKey: []byte("{}"), EncryptedPrivateKey: []byte("jwe")},
+			},
+		},
+	}
+	p, warnings = cloneProvisioner(jwk, "jwk-2")
+	assert.Nil(t, p.Details)
+	assert.Len(t, warnings, 1)
+	assert.NotNil(t, jwk.Details.GetJWK())
+}
@@ -0,0 +1,113 @@
+package provisionerbeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.step.sm/linkedca"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxDiffValueLen is the maximum length of a value printed in a diff. Longer
+// values, like base64 encoded templates, are truncated.
+const maxDiffValueLen = 64
+
+// fieldDiff represents a change in a provisioner field.
+type fieldDiff struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// diffProvisioners returns the fields that have changed between old and new,
+// sorted by name. Nested fields are joined using a dot, and the names are the
+// ones used in the JSON representation of the provisioner.
+func diffProvisioners(old, new *linkedca.Provisioner) ([]fieldDiff, error) {
+	a, err := flattenProvisioner(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := flattenProvisioner(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []fieldDiff
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			diffs = append(diffs, fieldDiff{Field: k, Old: v, New: w})
+		}
+	}
+	for k, w := range b {
+		if _, ok := a[k]; !ok {
+			diffs = append(diffs, fieldDiff{Field: k, New: w})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Field < diffs[j].Field
+	})
+	return diffs, nil
+}
+
+// printDiff writes the given changes to w.
+func printDiff(w io.Writer, name string, diffs []fieldDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintf(w, "No changes to provisioner %s.\n", name)
+		return
+	}
+	fmt.Fprintf(w, "Changes to provisioner %s:\n", name)
+	for _, d := range diffs {
+		fmt.Fprintf(w, "  %s: %s → %s\n", d.Field, diffValue(d.Old), diffValue(d.New))
+	}
+}
+
+func diffValue(s string) string {
+	switch {
+	case s == "":
+		return "<none>"
+	case len(s) > maxDiffValueLen:
+		return s[:maxDiffValueLen] + "..."
+	default:
+		return s
+	}
+}
+
+// flattenProvisioner returns a map with the JSON encoded value of every leaf
+// field in the provisioner. Lists are considered leaf values.
+func flattenProvisioner(p *linkedca.Provisioner) (map[string]string, error) {
+	b, err := protojson.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	m := make(map[string]string)
+	if err := flatten(m, "", v); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func flatten(m map[string]string, prefix string, v interface{}) error {
+	if obj, ok := v.(map[string]interface{}); ok {
+		for k, vv := range obj {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			if err := flatten(m, k, vv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m[prefix] = string(b)
+	return nil
+}
@@ -0,0 +1,122 @@
+package provisionerbeta
+
+import (
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/cli-utils/ui"
+)
+
+func exportCommand() cli.Command {
+	return cli.Command{
+		Name:   "export",
+		Action: cli.ActionFunc(exportAction),
+		Usage:  "export a provisioner from the CA configuration",
+		UsageText: `**step beta ca provisioner export** <name> [**--out**=<file>] [**--format**=<format>]
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "out",
+				Usage: `The <file> to write the provisioner to. Defaults to STDOUT.`,
+			},
+			cli.StringFlag{
+				Name:  "format",
+				Value: "json",
+				Usage: `The output format for the provisioner.
+
+: <format> is a string and must be one of:
+
+    **json**
+    :  Export the provisioner in JSON format. (default)
+
+    **yaml**
+    :  Export the provisioner in YAML format.`,
+			},
+			flags.Force,
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step beta ca provisioner export** exports the full configuration
+of a provisioner, including its claims, templates and encrypted private key, as
+JSON or YAML. The output can be used to back up a provisioner or to migrate it to a
+different CA.
+
+## POSITIONAL ARGUMENTS
+
+<name>
+: The name of the provisioner.
+
+## EXAMPLES
+
+Print a provisioner as JSON:
+'''
+$ step beta ca provisioner export acme
+'''
+
+Export a provisioner to a file:
+'''
+$ step beta ca provisioner export acme --out acme.json
+'''
+
+Export a provisioner to a YAML file:
+'''
+$ step beta ca provisioner export acme --format yaml --out acme.yaml
+'''
+`,
+	}
+}
+
+func exportAction(ctx *cli.Context) (err error) {
+	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+		return err
+	}
+
+	args := ctx.Args()
+	name := args.Get(0)
+
+	format := ctx.String("format")
+	if err := validateFormat(ctx, format); err != nil {
+		return err
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	p, err := client.GetProvisioner(ca.WithProvisionerName(name))
+	if err != nil {
+		return err
+	}
+
+	out := ctx.String("out")
+	if out == "" {
+		return printProvisionerFormat(p, format)
+	}
+
+	b, err := marshalProvisionerFormat(p, format)
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFile(out, append(b, '\n'), 0600); err != nil {
+		return err
+	}
+
+	ui.Printf("Your provisioner has been saved in %s.\n", out)
+	return nil
+}
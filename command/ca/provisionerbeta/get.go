@@ -1,16 +1,18 @@
 package provisionerbeta
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/pkg/errors"
 	"github.com/smallstep/certificates/ca"
 	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/jose"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
-	"google.golang.org/protobuf/encoding/protojson"
+	"go.step.sm/linkedca"
 )
 
 func getCommand() cli.Command {
@@ -18,28 +20,83 @@ func getCommand() cli.Command {
 		Name:   "get",
 		Action: cli.ActionFunc(getAction),
 		Usage:  "get a provisioner from the CA configuration",
-		UsageText: `**step beta ca provisioner get** <name>
+		UsageText: `**step beta ca provisioner get** <name|kid> [**--format**=<format>] [**--thumbprint**] [**--show-key-thumbprint**] [**--verbose**]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]`,
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
 		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Value: "json",
+				Usage: `The output format for printing the provisioner.
+
+: <format> is a string and must be one of:
+
+    **json**
+    :  Print output in JSON format. (default)
+
+    **yaml**
+    :  Print output in YAML format.`,
+			},
+			cli.BoolFlag{
+				Name: "thumbprint",
+				Usage: `Print only the thumbprint (RFC7638) of the public key of a JWK provisioner.
+The thumbprint is printed as a base64-urlencoded string.`,
+			},
+			cli.BoolFlag{
+				Name: "show-key-thumbprint",
+				Usage: `Print the SHA-256 thumbprint (RFC7638) of the public key of a JWK provisioner
+to STDERR after the provisioner. Nothing is printed for other provisioner types.`,
+			},
+			cli.BoolFlag{
+				Name: "verbose",
+				Usage: `Print if the argument was resolved as a provisioner name or a key-id, and the
+retried requests to the admin API.`,
+			},
 			flags.AdminCert,
 			flags.AdminKey,
 			flags.AdminProvisioner,
 			flags.AdminSubject,
 			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
 			flags.CaURL,
 			flags.Root,
 			flags.Context,
 		},
 		Description: `**step beta ca provisioner get** gets a provisioner from the CA configuration.
 
+The argument is first looked up as a provisioner name, and then as the key-id
+of a JWK provisioner.
+
 ## EXAMPLES
 
 Get a provisioner by name:
 '''
 $ step beta ca provisioner get acme
 '''
+
+Get a JWK provisioner by key-id:
+'''
+$ step beta ca provisioner get nvgnR8wSzpUlrt_tC3mvrhwhBx9Y7T1WL_JjcFVWYBQ
+'''
+
+Get a provisioner by name in YAML format:
+'''
+$ step beta ca provisioner get acme --format yaml
+'''
+
+Get the thumbprint of the public key of a JWK provisioner:
+'''
+$ step beta ca provisioner get admin --thumbprint
+'''
+
+Get a provisioner and the thumbprint of its key, if it has one:
+'''
+$ step beta ca provisioner get admin --show-key-thumbprint
+'''
 `,
 	}
 }
@@ -52,26 +109,101 @@ func getAction(ctx *cli.Context) (err error) {
 	args := ctx.Args()
 	name := args.Get(0)
 
+	format := ctx.String("format")
+	if err := validateFormat(ctx, format); err != nil {
+		return err
+	}
+	if ctx.Bool("thumbprint") && ctx.Bool("show-key-thumbprint") {
+		return errs.IncompatibleFlagWithFlag(ctx, "show-key-thumbprint", "thumbprint")
+	}
+
 	// Create online client
 	client, err := cautils.NewAdminClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	p, err := client.GetProvisioner(ca.WithProvisionerName(name))
+	p, err := getProvisionerByNameOrKid(ctx, client, name)
 	if err != nil {
 		return err
 	}
 
-	var buf bytes.Buffer
-	b, err := protojson.Marshal(p)
-	if err != nil {
-		return err
+	if ctx.Bool("thumbprint") {
+		return printJWKThumbprint(p)
 	}
-	if err := json.Indent(&buf, b, "", "  "); err != nil {
+
+	if err = printProvisionerFormat(p, format); err != nil {
 		return err
 	}
-	fmt.Println(buf.String())
+	if ctx.Bool("show-key-thumbprint") {
+		var thumbprint string
+		var ok bool
+		if thumbprint, ok, err = jwkThumbprint(p); err != nil {
+			return err
+		}
+		if ok {
+			fmt.Fprintf(os.Stderr, "Key thumbprint: %s\n", thumbprint)
+		}
+	}
+	return nil
+}
+
+// getProvisionerByNameOrKid returns the provisioner with the given name or,
+// if it does not exist, the JWK provisioner with the given key id.
+func getProvisionerByNameOrKid(ctx *cli.Context, client *ca.AdminClient, nameOrKid string) (*linkedca.Provisioner, error) {
+	p, err := client.GetProvisioner(ca.WithProvisionerName(nameOrKid))
+	if err == nil {
+		if ctx.Bool("verbose") {
+			fmt.Fprintf(os.Stderr, "Resolved %s as a provisioner name.\n", nameOrKid)
+		}
+		return p, nil
+	}
+
+	provisioners, lerr := client.GetProvisioners()
+	if lerr != nil {
+		return nil, err
+	}
+	prov, byName, ok := cautils.LookupProvisioner(provisioners, nameOrKid)
+	if !ok || byName {
+		return nil, err
+	}
+	if p, err = client.GetProvisioner(ca.WithProvisionerName(prov.GetName())); err != nil {
+		return nil, err
+	}
+	if ctx.Bool("verbose") {
+		fmt.Fprintf(os.Stderr, "Resolved %s as the key-id of the provisioner %s.\n", nameOrKid, prov.GetName())
+	}
+	return p, nil
+}
 
+// printJWKThumbprint prints the thumbprint of the public key of the given JWK
+// provisioner.
+func printJWKThumbprint(p *linkedca.Provisioner) error {
+	thumbprint, ok, err := jwkThumbprint(p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("provisioner %s is not a JWK provisioner", p.Name)
+	}
+	fmt.Println(thumbprint)
 	return nil
 }
+
+// jwkThumbprint returns the thumbprint of the public key of the given
+// provisioner. The second value is false if it is not a JWK provisioner.
+func jwkThumbprint(p *linkedca.Provisioner) (string, bool, error) {
+	data, ok := p.Details.GetData().(*linkedca.ProvisionerDetails_JWK)
+	if !ok {
+		return "", false, nil
+	}
+	var jwk jose.JSONWebKey
+	if err := json.Unmarshal(data.JWK.PublicKey, &jwk); err != nil {
+		return "", false, errors.Wrap(err, "error parsing provisioner public key")
+	}
+	thumbprint, err := jose.Thumbprint(&jwk)
+	if err != nil {
+		return "", false, err
+	}
+	return thumbprint, true, nil
+}
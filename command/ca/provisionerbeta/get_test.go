@@ -0,0 +1,52 @@
+package provisionerbeta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/smallstep/cli/jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.step.sm/linkedca"
+)
+
+func TestJWKThumbprint(t *testing.T) {
+	jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	require.NoError(t, err)
+	pub := jwk.Public()
+	b, err := json.Marshal(&pub)
+	require.NoError(t, err)
+	want, err := jose.Thumbprint(&pub)
+	require.NoError(t, err)
+
+	p := &linkedca.Provisioner{
+		Type: linkedca.Provisioner_JWK,
+		Name: "jwk",
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_JWK{
+				JWK: &linkedca.JWKProvisioner{PublicKey: b},
+			},
+		},
+	}
+	thumbprint, ok, err := jwkThumbprint(p)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, want, thumbprint)
+
+	p.Details.GetJWK().PublicKey = []byte("not a key")
+	_, _, err = jwkThumbprint(p)
+	assert.Error(t, err)
+
+	// Provisioners without a JWK are skipped.
+	acme := &linkedca.Provisioner{
+		Type: linkedca.Provisioner_ACME,
+		Name: "acme",
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_ACME{ACME: &linkedca.ACMEProvisioner{}},
+		},
+	}
+	thumbprint, ok, err = jwkThumbprint(acme)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "", thumbprint)
+}
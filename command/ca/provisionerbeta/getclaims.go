@@ -0,0 +1,150 @@
+package provisionerbeta
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/authority/config"
+	"github.com/smallstep/certificates/authority/provisioner"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+)
+
+func getClaimsCommand() cli.Command {
+	return cli.Command{
+		Name:   "get-claims",
+		Action: cli.ActionFunc(getClaimsAction),
+		Usage:  "print the effective claims of a provisioner",
+		UsageText: `**step beta ca provisioner get-claims** <name>
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step beta ca provisioner get-claims** prints the effective claims of a
+provisioner as a JSON object, using the same keys as the claims in the CA
+configuration. Each claim has the "value" used and its "source": "provisioner"
+if it is set in the provisioner, or "default" if the default value is used.
+
+The default values are the built-in defaults of the CA, the global claims in
+the "authority" section of the CA configuration, if any, are not available
+through the admin API and are not taken into account.
+
+## POSITIONAL ARGUMENTS
+
+<name>
+: The name of the provisioner.
+
+## EXIT CODES
+
+This command returns '0' on success, '3' if the provisioner does not exist, and
+'1' for any other error.
+
+## EXAMPLES
+
+Print the effective claims of the provisioner "ci":
+'''
+$ step beta ca provisioner get-claims ci
+'''
+`,
+	}
+}
+
+func getClaimsAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+		return err
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	p, err := client.GetProvisioner(ca.WithProvisionerName(ctx.Args().Get(0)))
+	if err != nil {
+		return notFoundExitError(err)
+	}
+
+	b, err := json.MarshalIndent(effectiveClaims(p.Claims), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error marshaling claims")
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// claimValue is the value of a claim and where it comes from.
+type claimValue struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// claimsValues are the effective claims of a provisioner.
+type claimsValues struct {
+	MinTLSDur               claimValue `json:"minTLSCertDuration"`
+	MaxTLSDur               claimValue `json:"maxTLSCertDuration"`
+	DefaultTLSDur           claimValue `json:"defaultTLSCertDuration"`
+	MinUserSSHDur           claimValue `json:"minUserSSHCertDuration"`
+	MaxUserSSHDur           claimValue `json:"maxUserSSHCertDuration"`
+	DefaultUserSSHDur       claimValue `json:"defaultUserSSHCertDuration"`
+	MinHostSSHDur           claimValue `json:"minHostSSHCertDuration"`
+	MaxHostSSHDur           claimValue `json:"maxHostSSHCertDuration"`
+	DefaultHostSSHDur       claimValue `json:"defaultHostSSHCertDuration"`
+	EnableSSHCA             claimValue `json:"enableSSHCA"`
+	DisableRenewal          claimValue `json:"disableRenewal"`
+	AllowRenewalAfterExpiry claimValue `json:"allowRenewalAfterExpiry"`
+}
+
+// effectiveClaims merges the given provisioner claims with the default claims
+// of the CA. Durations are set in the provisioner if they are not empty, and
+// booleans if they are different than the default value.
+func effectiveClaims(c *linkedca.Claims) *claimsValues {
+	g := config.GlobalProvisionerClaims
+	duration := func(value string, def *provisioner.Duration) claimValue {
+		if value != "" {
+			return claimValue{Value: value, Source: "provisioner"}
+		}
+		return claimValue{Value: def.Duration.String(), Source: "default"}
+	}
+	boolean := func(value bool, def *bool) claimValue {
+		if value != *def {
+			return claimValue{Value: value, Source: "provisioner"}
+		}
+		return claimValue{Value: *def, Source: "default"}
+	}
+
+	x509 := c.GetX509().GetDurations()
+	user := c.GetSsh().GetUserDurations()
+	host := c.GetSsh().GetHostDurations()
+	return &claimsValues{
+		MinTLSDur:               duration(x509.GetMin(), g.MinTLSDur),
+		MaxTLSDur:               duration(x509.GetMax(), g.MaxTLSDur),
+		DefaultTLSDur:           duration(x509.GetDefault(), g.DefaultTLSDur),
+		MinUserSSHDur:           duration(user.GetMin(), g.MinUserSSHDur),
+		MaxUserSSHDur:           duration(user.GetMax(), g.MaxUserSSHDur),
+		DefaultUserSSHDur:       duration(user.GetDefault(), g.DefaultUserSSHDur),
+		MinHostSSHDur:           duration(host.GetMin(), g.MinHostSSHDur),
+		MaxHostSSHDur:           duration(host.GetMax(), g.MaxHostSSHDur),
+		DefaultHostSSHDur:       duration(host.GetDefault(), g.DefaultHostSSHDur),
+		EnableSSHCA:             boolean(c.GetSsh().GetEnabled(), g.EnableSSHCA),
+		DisableRenewal:          boolean(c.GetDisableRenewal(), g.DisableRenewal),
+		AllowRenewalAfterExpiry: boolean(c.GetAllowRenewalAfterExpiry(), g.AllowRenewalAfterExpiry),
+	}
+}
@@ -0,0 +1,41 @@
+package provisionerbeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.step.sm/linkedca"
+)
+
+func TestEffectiveClaims(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		got := effectiveClaims(nil)
+		assert.Equal(t, claimValue{Value: "5m0s", Source: "default"}, got.MinTLSDur)
+		assert.Equal(t, claimValue{Value: "24h0m0s", Source: "default"}, got.DefaultTLSDur)
+		assert.Equal(t, claimValue{Value: "720h0m0s", Source: "default"}, got.MaxHostSSHDur)
+		assert.Equal(t, claimValue{Value: false, Source: "default"}, got.EnableSSHCA)
+		assert.Equal(t, claimValue{Value: false, Source: "default"}, got.DisableRenewal)
+	})
+
+	t.Run("provisioner", func(t *testing.T) {
+		got := effectiveClaims(&linkedca.Claims{
+			X509: &linkedca.X509Claims{
+				Enabled:   true,
+				Durations: &linkedca.Durations{Max: "48h", Default: "12h"},
+			},
+			Ssh: &linkedca.SSHClaims{
+				Enabled:       true,
+				UserDurations: &linkedca.Durations{Min: "1m"},
+			},
+			DisableRenewal: true,
+		})
+		assert.Equal(t, claimValue{Value: "5m0s", Source: "default"}, got.MinTLSDur)
+		assert.Equal(t, claimValue{Value: "48h", Source: "provisioner"}, got.MaxTLSDur)
+		assert.Equal(t, claimValue{Value: "12h", Source: "provisioner"}, got.DefaultTLSDur)
+		assert.Equal(t, claimValue{Value: "1m", Source: "provisioner"}, got.MinUserSSHDur)
+		assert.Equal(t, claimValue{Value: "16h0m0s", Source: "default"}, got.DefaultUserSSHDur)
+		assert.Equal(t, claimValue{Value: true, Source: "provisioner"}, got.EnableSSHCA)
+		assert.Equal(t, claimValue{Value: true, Source: "provisioner"}, got.DisableRenewal)
+		assert.Equal(t, claimValue{Value: false, Source: "default"}, got.AllowRenewalAfterExpiry)
+	})
+}
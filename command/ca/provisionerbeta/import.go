@@ -0,0 +1,166 @@
+package provisionerbeta
+
+import (
+	"github.com/pkg/errors"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func importCommand() cli.Command {
+	return cli.Command{
+		Name:   "import",
+		Action: cli.ActionFunc(importAction),
+		Usage:  "import a provisioner into the CA configuration",
+		UsageText: `**step beta ca provisioner import** <file>
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step beta ca provisioner import** creates a provisioner from a
+JSON or YAML file, like the ones generated by **step beta ca provisioner export**.
+Files with a ".yaml" or ".yml" extension are read as YAML.
+
+The identifiers and timestamps of the exported provisioner are discarded, the
+CA will assign new ones.
+
+## POSITIONAL ARGUMENTS
+
+<file>
+: The <file> with the JSON or YAML representation of the provisioner. A hyphen ("-")
+indicates STDIN as <file>.
+
+## EXAMPLES
+
+Import a provisioner from a file:
+'''
+$ step beta ca provisioner import acme.json
+'''
+
+Import a provisioner from a YAML file:
+'''
+$ step beta ca provisioner import acme.yaml
+'''
+
+Copy a provisioner from one CA to another:
+'''
+$ step beta ca provisioner export acme --context staging \
+  | step beta ca provisioner import - --context production
+'''
+`,
+	}
+}
+
+func importAction(ctx *cli.Context) (err error) {
+	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+		return err
+	}
+
+	filename := ctx.Args().Get(0)
+	p, err := readProvisioner(filename)
+	if err != nil {
+		return err
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	exists, err := provisionerExists(client, p.Name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.Errorf("error importing %s: a provisioner with name %s already exists", filename, p.Name)
+	}
+
+	if p, err = client.CreateProvisioner(p); err != nil {
+		return err
+	}
+
+	return printProvisioner(p)
+}
+
+// readProvisioner reads a JSON or YAML provisioner from the given file. Unknown fields
+// are not allowed, and the identifiers and timestamps are removed so the
+// provisioner can be created in any CA.
+func readProvisioner(filename string) (*linkedca.Provisioner, error) {
+	b, err := readJSONFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(linkedca.Provisioner)
+	if err := protojson.Unmarshal(b, p); err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", filename)
+	}
+
+	if p.Type == linkedca.Provisioner_NOOP {
+		return nil, errors.Errorf("error parsing %s: provisioner type is required", filename)
+	}
+	if _, ok := linkedca.Provisioner_Type_name[int32(p.Type)]; !ok {
+		return nil, errors.Errorf("error parsing %s: unsupported provisioner type %d", filename, p.Type)
+	}
+	if p.Name == "" {
+		return nil, errors.Errorf("error parsing %s: provisioner name is required", filename)
+	}
+	if p.Details == nil || p.Details.GetData() == nil {
+		return nil, errors.Errorf("error parsing %s: provisioner details are required", filename)
+	}
+	if typ := detailsType(p.Details); typ != p.Type {
+		return nil, errors.Errorf("error parsing %s: provisioner type %s does not match the %s details", filename, p.Type, typ)
+	}
+
+	p.Id = ""
+	p.AuthorityId = ""
+	p.CreatedAt = nil
+	p.DeletedAt = nil
+	return p, nil
+}
+
+// detailsType returns the provisioner type corresponding to the given details.
+func detailsType(d *linkedca.ProvisionerDetails) linkedca.Provisioner_Type {
+	switch d.GetData().(type) {
+	case *linkedca.ProvisionerDetails_JWK:
+		return linkedca.Provisioner_JWK
+	case *linkedca.ProvisionerDetails_OIDC:
+		return linkedca.Provisioner_OIDC
+	case *linkedca.ProvisionerDetails_GCP:
+		return linkedca.Provisioner_GCP
+	case *linkedca.ProvisionerDetails_AWS:
+		return linkedca.Provisioner_AWS
+	case *linkedca.ProvisionerDetails_Azure:
+		return linkedca.Provisioner_AZURE
+	case *linkedca.ProvisionerDetails_ACME:
+		return linkedca.Provisioner_ACME
+	case *linkedca.ProvisionerDetails_X5C:
+		return linkedca.Provisioner_X5C
+	case *linkedca.ProvisionerDetails_K8SSA:
+		return linkedca.Provisioner_K8SSA
+	case *linkedca.ProvisionerDetails_SSHPOP:
+		return linkedca.Provisioner_SSHPOP
+	case *linkedca.ProvisionerDetails_SCEP:
+		return linkedca.Provisioner_SCEP
+	case *linkedca.ProvisionerDetails_Nebula:
+		return linkedca.Provisioner_NEBULA
+	default:
+		return linkedca.Provisioner_NOOP
+	}
+}
@@ -1,13 +1,40 @@
 package provisionerbeta
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/Masterminds/sprig/v3"
 	"github.com/pkg/errors"
 	nebula "github.com/slackhq/nebula/cert"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/crypto/pemutil"
+	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
+	"go.step.sm/cli-utils/ui"
+	"go.step.sm/linkedca"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"sigs.k8s.io/yaml"
 )
 
 // Command returns the jwk subcommand.
@@ -19,9 +46,17 @@ func Command() cli.Command {
 		Subcommands: cli.Commands{
 			//listCommand(),
 			addCommand(),
+			cloneCommand(),
 			removeCommand(),
+			renameCommand(),
 			getCommand(),
+			getClaimsCommand(),
 			updateCommand(),
+			exportCommand(),
+			importCommand(),
+			templateCommand(),
+			validateRootsCommand(),
+			verifyTokenCommand(),
 		},
 		Description: `**step beta ca provisioner** command group provides facilities for managing the
 certificate authority provisioners.
@@ -67,60 +102,599 @@ Add a single provisioner:
 $ step beta ca provisioner add max@smallstep.com --type JWK --create
 '''
 
+Create a copy of a provisioner with a new name:
+'''
+$ step beta ca provisioner clone acme acme-staging
+'''
+
 Remove a provisioner:
 '''
 $ step beta ca provisioner remove max@smallstep.com
+'''
+
+Export a provisioner to a file:
+'''
+$ step beta ca provisioner export max@smallstep.com --out max.json
+'''
+
+Import a provisioner from a file:
+'''
+$ step beta ca provisioner import max.json
 '''`,
 	}
 }
 
+// scepEncryptionAlgorithms maps the SCEP encryption algorithm identifiers,
+// used as the index, to their names.
+var scepEncryptionAlgorithms = []string{
+	"des-cbc",
+	"aes-128-cbc",
+	"aes-256-cbc",
+	"aes-128-gcm",
+	"aes-256-gcm",
+}
+
+// parseSCEPEncryptionAlgorithm returns the identifier of the SCEP encryption
+// algorithm in the --encryption-algorithm-identifier flag. The flag accepts
+// both the numeric identifier and the name of the algorithm.
+func parseSCEPEncryptionAlgorithm(ctx *cli.Context) (int32, error) {
+	value := ctx.String("encryption-algorithm-identifier")
+	if value == "" {
+		return 0, nil
+	}
+	for i, name := range scepEncryptionAlgorithms {
+		if strings.EqualFold(value, name) || value == strconv.Itoa(i) {
+			return int32(i), nil
+		}
+	}
+	return 0, errs.InvalidFlagValue(ctx, "encryption-algorithm-identifier", value,
+		"0 - 4, "+strings.Join(scepEncryptionAlgorithms, ", "))
+}
+
+// scepMinimumPublicKeyLengths are the allowed values of the
+// --min-public-key-length flag.
+var scepMinimumPublicKeyLengths = []int{1024, 2048, 3072, 4096}
+
+// parseSCEPMinimumPublicKeyLength returns the value of the
+// --min-public-key-length flag. A warning is printed if the value is lower
+// than 2048.
+func parseSCEPMinimumPublicKeyLength(ctx *cli.Context) (int32, error) {
+	if !ctx.IsSet("min-public-key-length") {
+		return 0, nil
+	}
+	length := ctx.Int("min-public-key-length")
+	for _, l := range scepMinimumPublicKeyLengths {
+		if length == l {
+			if length < 2048 {
+				ui.Printf("Warning: a minimum public key length of %d bits is insecure, 2048 or more is recommended.\n", length)
+			}
+			return int32(length), nil
+		}
+	}
+	return 0, errs.InvalidFlagValue(ctx, "min-public-key-length", strconv.Itoa(length), "1024, 2048, 3072, 4096")
+}
+
+// parseAzureAudience returns the value of the --azure-audience flag, checking
+// that it is an absolute URI.
+func parseAzureAudience(ctx *cli.Context) (string, error) {
+	if !ctx.IsSet("azure-audience") {
+		return "", nil
+	}
+	audience := ctx.String("azure-audience")
+	if u, err := url.Parse(audience); err != nil || audience == "" || !u.IsAbs() {
+		return "", errs.InvalidFlagValueMsg(ctx, "azure-audience", audience, "value must be an absolute URI")
+	}
+	return audience, nil
+}
+
+// parseInstanceAge returns the value of the --instance-age flag. A zero
+// duration returns an empty string, which clears the instance age of a
+// provisioner. A warning is printed if the value exceeds the
+// --instance-age-warning threshold.
 func parseInstanceAge(ctx *cli.Context) (age string, err error) {
 	if !ctx.IsSet("instance-age") {
 		return
 	}
-	age = ctx.String("instance-age")
+	value := ctx.String("instance-age")
+	age = expandDayUnits(value)
 	dur, err := time.ParseDuration(age)
 	if err != nil {
-		return "", err
+		return "", errs.InvalidFlagValueMsg(ctx, "instance-age", value, err.Error())
 	}
-	if dur < 0 {
+	switch {
+	case dur < 0:
 		return "", errs.MinSizeFlag(ctx, "instance-age", "0s")
+	case dur == 0:
+		return "", nil
+	}
+	// The CA does not support days and weeks.
+	if age != value {
+		age = dur.String()
+	}
+	if threshold := ctx.Duration("instance-age-warning"); threshold > 0 && dur > threshold {
+		ui.Printf("Warning: an instance age of %s is greater than %s and allows old instances to get certificates.\n", dur, threshold)
 	}
 	return
 }
 
+var dayUnitsRegexp = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+// expandDayUnits replaces the days ("d") and weeks ("w") in the given duration
+// with the equivalent hours, so it can be parsed with time.ParseDuration, e.g.
+// "1d12h" becomes "24h12h". Other units are not modified.
+func expandDayUnits(s string) string {
+	return dayUnitsRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		v, err := strconv.ParseFloat(m[:len(m)-1], 64)
+		if err != nil {
+			return m
+		}
+		hours := v * 24
+		if m[len(m)-1] == 'w' {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+}
+
+// marshalProvisioner returns the given provisioner as indented JSON.
+func marshalProvisioner(p *linkedca.Provisioner) ([]byte, error) {
+	var buf bytes.Buffer
+	b, err := protojson.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// printProvisioner prints the given provisioner as indented JSON to stdout.
+func printProvisioner(p *linkedca.Provisioner) error {
+	return printProvisionerFormat(p, "json")
+}
+
+// readJSONFile reads the given file and returns its contents as JSON. Files
+// with a .yaml or .yml extension are converted from YAML to JSON.
+func readJSONFile(filename string) ([]byte, error) {
+	b, err := utils.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !isYAMLFile(filename) {
+		return b, nil
+	}
+	if b, err = yaml.YAMLToJSON(b); err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", filename)
+	}
+	return b, nil
+}
+
+// isYAMLFile returns true if the given filename has a YAML extension.
+func isYAMLFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// validateFormat checks that the given output format is supported.
+func validateFormat(ctx *cli.Context, format string) error {
+	switch format {
+	case "json", "yaml":
+		return nil
+	default:
+		return errs.InvalidFlagValue(ctx, "format", format, "json, yaml")
+	}
+}
+
+// marshalProvisionerFormat returns the representation of the provisioner in
+// the given format, json or yaml.
+func marshalProvisionerFormat(p *linkedca.Provisioner, format string) ([]byte, error) {
+	b, err := marshalProvisioner(p)
+	if err != nil || format != "yaml" {
+		return b, err
+	}
+	if b, err = yaml.JSONToYAML(b); err != nil {
+		return nil, errors.Wrap(err, "error marshaling provisioner")
+	}
+	return bytes.TrimSuffix(b, []byte("\n")), nil
+}
+
+// printProvisionerFormat prints the provisioner in the given format, json or
+// yaml.
+func printProvisionerFormat(p *linkedca.Provisioner, format string) error {
+	b, err := marshalProvisionerFormat(p, format)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// provisionerExists returns true if the CA already has a provisioner with the
+// given name.
+func provisionerExists(client *ca.AdminClient, name string) (bool, error) {
+	p, err := findProvisioner(client, name)
+	return p != nil, err
+}
+
+// findProvisioner returns the provisioner with the given name, or nil if it
+// does not exist.
+func findProvisioner(client *ca.AdminClient, name string) (*linkedca.Provisioner, error) {
+	p, err := client.GetProvisioner(ca.WithProvisionerName(name))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// notFoundExitCode is the exit code used when the provisioner to remove does
+// not exist.
+const notFoundExitCode = 3
+
+// isNotFound returns true if the given error is a not found error returned by
+// the admin API.
+func isNotFound(err error) bool {
+	var adminErr *ca.AdminClientError
+	return errors.As(err, &adminErr) && adminErr.Type == "notFound"
+}
+
+// isAlreadyExists returns true if the given error is the error returned by the
+// admin API when a provisioner with the given name already exists.
+func isAlreadyExists(err error, name string) bool {
+	var adminErr *ca.AdminClientError
+	return errors.As(err, &adminErr) && adminErr.Type == "badRequest" &&
+		strings.Contains(adminErr.Message, "provisioner with name "+name+" already exists")
+}
+
+// provisionerCreator is the part of the admin client used to create a
+// provisioner.
+type provisionerCreator interface {
+	CreateProvisioner(prov *linkedca.Provisioner) (*linkedca.Provisioner, error)
+	RemoveProvisioner(opts ...ca.ProvisionerOption) error
+}
+
+// createProvisioner creates the given provisioner. If the CA reports that a
+// provisioner with the same name already exists and force is true, the
+// existing provisioner is removed and the new one is created again.
+func createProvisioner(client provisionerCreator, p *linkedca.Provisioner, force bool, w io.Writer) (*linkedca.Provisioner, error) {
+	created, err := client.CreateProvisioner(p)
+	if err == nil || !force || !isAlreadyExists(err, p.Name) {
+		return created, err
+	}
+
+	fmt.Fprintf(w, "WARNING: provisioner %s already exists and it will be removed and created again.\n"+
+		"The provisioner id and keys change, the credentials issued using the previous keys will not be accepted.\n", p.Name)
+	if err := client.RemoveProvisioner(ca.WithProvisionerName(p.Name)); err != nil {
+		return nil, errors.Wrapf(err, "error removing provisioner %s", p.Name)
+	}
+	return client.CreateProvisioner(p)
+}
+
+// provisionerGetter is the part of the admin client used to get a
+// provisioner.
+type provisionerGetter interface {
+	GetProvisioner(opts ...ca.ProvisionerOption) (*linkedca.Provisioner, error)
+}
+
+// printBackend prints to w the backend used to manage the provisioners when
+// --dry-run is used. The beta commands always use the admin API.
+func printBackend(ctx *cli.Context, w io.Writer) error {
+	caURL, err := flags.ParseCaURLIfExists(ctx)
+	if err != nil {
+		return err
+	}
+	if caURL == "" {
+		caURL = "a CA URL not set"
+	}
+	fmt.Fprintf(w, "Backend: admin API at %s, no changes will be made.\n", caURL)
+	return nil
+}
+
+// waitForChange waits for the change from old to p to be visible in the CA if
+// the --wait flag is set. The old provisioner is nil if p has been created, p
+// is then the provisioner returned by the CA.
+func waitForChange(ctx *cli.Context, client provisionerGetter, old, p *linkedca.Provisioner) error {
+	if !ctx.Bool("wait") {
+		return nil
+	}
+	return waitForProvisioner(client, old, p, ctx.Duration("wait-interval"), ctx.Duration("wait-timeout"))
+}
+
+// waitForProvisioner requests the provisioner with the name of the given one
+// every interval until it has the changes from old, or the timeout elapses.
+func waitForProvisioner(client provisionerGetter, old, p *linkedca.Provisioner, interval, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		got, err := client.GetProvisioner(ca.WithProvisionerName(p.Name))
+		if err == nil && provisionerMatches(got, old, p) {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if err != nil && !isNotFound(err) {
+				return errors.Wrapf(err, "error waiting for provisioner %s", p.Name)
+			}
+			return errors.Errorf("error waiting for provisioner %s: the change is not visible after %s", p.Name, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// provisionerMatches returns true if the provisioner returned by the CA has
+// the fields that changed from old to want. The CA can set defaults on the
+// other fields, so they are not compared. If old is nil, the provisioner only
+// needs to exist, with the id of want if it has one.
+func provisionerMatches(got, old, want *linkedca.Provisioner) bool {
+	if old == nil {
+		return want.Id == "" || got.Id == want.Id
+	}
+	return changedFieldsMatch(got.ProtoReflect(), old.ProtoReflect(), want.ProtoReflect())
+}
+
+// changedFieldsMatch returns true if got has the same value as want in the
+// fields where want differs from old. Messages are compared field by field,
+// and the timestamps are ignored.
+func changedFieldsMatch(got, old, want protoreflect.Message) bool {
+	fields := want.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() != nil && fd.Message().FullName() == "google.protobuf.Timestamp" {
+			continue
+		}
+		if fieldEqual(old, want, fd) {
+			continue
+		}
+		if fd.Message() != nil && fd.Cardinality() != protoreflect.Repeated && old.Has(fd) && want.Has(fd) {
+			if !changedFieldsMatch(got.Get(fd).Message(), old.Get(fd).Message(), want.Get(fd).Message()) {
+				return false
+			}
+			continue
+		}
+		if !fieldEqual(got, want, fd) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldEqual returns true if the field fd has the same value in a and b.
+func fieldEqual(a, b protoreflect.Message, fd protoreflect.FieldDescriptor) bool {
+	x, y := a.Type().New(), b.Type().New()
+	if a.Has(fd) {
+		x.Set(fd, a.Get(fd))
+	}
+	if b.Has(fd) {
+		y.Set(fd, b.Get(fd))
+	}
+	return proto.Equal(x.Interface(), y.Interface())
+}
+
+// notFoundExitError returns an error exiting with notFoundExitCode if the
+// given error is a not found error.
+func notFoundExitError(err error) error {
+	if isNotFound(err) {
+		return errs.NewExitError(err, notFoundExitCode)
+	}
+	return err
+}
+
+// validateClaims checks that the x509, ssh user and ssh host durations in the
+// given claims are valid.
+func validateClaims(ctx *cli.Context, c *linkedca.Claims) error {
+	if c == nil {
+		return nil
+	}
+	if err := validateDurations(ctx, "x509", c.GetX509().GetDurations()); err != nil {
+		return err
+	}
+	if err := validateDurations(ctx, "ssh-user", c.GetSsh().GetUserDurations()); err != nil {
+		return err
+	}
+	return validateDurations(ctx, "ssh-host", c.GetSsh().GetHostDurations())
+}
+
+// validateDurations checks that the given durations can be parsed and that the
+// default duration is between the minimum and maximum durations. The prefix is
+// used to name the conflicting flags, e.g. "x509" for "--x509-min-dur".
+func validateDurations(ctx *cli.Context, prefix string, d *linkedca.Durations) error {
+	if d == nil {
+		return nil
+	}
+
+	minFlag, maxFlag, defaultFlag := prefix+"-min-dur", prefix+"-max-dur", prefix+"-default-dur"
+	minDur, err := parseDuration(ctx, minFlag, d.Min)
+	if err != nil {
+		return err
+	}
+	maxDur, err := parseDuration(ctx, maxFlag, d.Max)
+	if err != nil {
+		return err
+	}
+	defaultDur, err := parseDuration(ctx, defaultFlag, d.Default)
+	if err != nil {
+		return err
+	}
+
+	if d.Min != "" && d.Max != "" && minDur > maxDur {
+		return errs.InvalidFlagValueMsg(ctx, minFlag, d.Min,
+			fmt.Sprintf("it must be less than or equal to '--%s %s'", maxFlag, d.Max))
+	}
+	if d.Default != "" && d.Min != "" && defaultDur < minDur {
+		return errs.InvalidFlagValueMsg(ctx, defaultFlag, d.Default,
+			fmt.Sprintf("it must be greater than or equal to '--%s %s'", minFlag, d.Min))
+	}
+	if d.Default != "" && d.Max != "" && defaultDur > maxDur {
+		return errs.InvalidFlagValueMsg(ctx, defaultFlag, d.Default,
+			fmt.Sprintf("it must be less than or equal to '--%s %s'", maxFlag, d.Max))
+	}
+	return nil
+}
+
+// parseDuration parses the duration set by the given flag. An empty value
+// returns a zero duration.
+func parseDuration(ctx *cli.Context, flag, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errs.InvalidFlagValueMsg(ctx, flag, value, err.Error())
+	}
+	if d < 0 {
+		return 0, errs.MinSizeFlag(ctx, flag, "0s")
+	}
+	return d, nil
+}
+
+// removeElements returns the list without all the occurrences of the given
+// elements, preserving the order of the remaining ones.
 func removeElements(list, rems []string) []string {
-	if len(list) == 0 {
+	if len(list) == 0 || len(rems) == 0 {
 		return list
 	}
+	remove := make(map[string]bool, len(rems))
 	for _, rem := range rems {
-		for i, elem := range list {
-			if elem == rem {
-				list[i] = list[len(list)-1]
-				list = list[:len(list)-1]
-				break
-			}
+		remove[rem] = true
+	}
+	result := make([]string, 0, len(list))
+	for _, elem := range list {
+		if !remove[elem] {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
+
+// removeAllElements is the value of the --remove flags of the cloud
+// provisioners that removes all the elements of the list.
+const removeAllElements = "*"
+
+// removeCloudElements returns the list without the given elements, or an empty
+// list if any of them is removeAllElements.
+func removeCloudElements(list, rems []string) []string {
+	for _, rem := range rems {
+		if rem == removeAllElements {
+			return []string{}
 		}
 	}
-	return list
+	return removeElements(list, rems)
+}
+
+// applyEnableSSHCA sets the ssh enabled claim to the value of the
+// --enable-ssh-ca flag, if the flag is set.
+func applyEnableSSHCA(ctx *cli.Context, claims *linkedca.Claims) error {
+	if !ctx.IsSet("enable-ssh-ca") {
+		return nil
+	}
+	if ctx.IsSet("ssh") {
+		return errs.MutuallyExclusiveFlags(ctx, "ssh", "enable-ssh-ca")
+	}
+	value := ctx.String("enable-ssh-ca")
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return errs.InvalidFlagValue(ctx, "enable-ssh-ca", value, "true, false")
+	}
+	if claims.Ssh == nil {
+		claims.Ssh = &linkedca.SSHClaims{}
+	}
+	claims.Ssh.Enabled = enabled
+	return nil
+}
+
+// validateEABFlags checks that the External Account Binding flags are not
+// used together, and that they are only used with ACME provisioners.
+func validateEABFlags(ctx *cli.Context, typ linkedca.Provisioner_Type) error {
+	requireEABSet := ctx.IsSet("require-eab")
+	disableEABSet := ctx.IsSet("disable-eab")
+	if requireEABSet && disableEABSet {
+		return errs.MutuallyExclusiveFlags(ctx, "require-eab", "disable-eab")
+	}
+	if typ != linkedca.Provisioner_ACME {
+		switch {
+		case requireEABSet:
+			return errors.Errorf("flag '--require-eab' cannot be used with %s provisioners: External Account Binding is only supported by ACME provisioners", typ)
+		case disableEABSet:
+			return errors.Errorf("flag '--disable-eab' cannot be used with %s provisioners: External Account Binding is only supported by ACME provisioners", typ)
+		}
+	}
+	return nil
+}
+
+// validateCloudFlags checks that the flags shared by the cloud provisioners
+// are only used with AWS, Azure and GCP provisioners.
+func validateCloudFlags(ctx *cli.Context, typ linkedca.Provisioner_Type) error {
+	switch typ {
+	case linkedca.Provisioner_AWS, linkedca.Provisioner_AZURE, linkedca.Provisioner_GCP:
+		return nil
+	}
+	for _, name := range []string{"disable-custom-sans", "disable-trust-on-first-use"} {
+		if ctx.IsSet(name) {
+			return errors.Errorf("flag '--%s' cannot be used with %s provisioners: it is only supported by AWS, Azure and GCP provisioners", name, typ)
+		}
+	}
+	return nil
+}
+
+// appendUniqueElements appends the given elements to the list, skipping the
+// ones already present. Duplicates already in the list are also removed.
+func appendUniqueElements(list, elems []string) []string {
+	seen := make(map[string]bool, len(list)+len(elems))
+	result := make([]string, 0, len(list)+len(elems))
+	for _, elem := range append(list, elems...) {
+		if !seen[elem] {
+			seen[elem] = true
+			result = append(result, elem)
+		}
+	}
+	return result
 }
 
 var (
 	x509TemplateFlag = cli.StringFlag{
-		Name:  "x509-template",
-		Usage: `The x509 certificate template <file>, a JSON representation of the certificate to create.`,
+		Name: "x509-template",
+		Usage: `The x509 certificate template <file>, a JSON representation of the certificate to create.
+The <file> can also be an https URL.`,
 	}
 	x509TemplateDataFlag = cli.StringFlag{
-		Name:  "x509-template-data",
-		Usage: `The x509 certificate template data <file>, a JSON map of data that can be used by the certificate template.`,
+		Name: "x509-template-data",
+		Usage: `The x509 certificate template data <file>, a JSON map of data that can be used by the certificate template.
+The <file> can also be an https URL.`,
+	}
+	x509TemplateDataJSONFlag = cli.StringFlag{
+		Name: "x509-template-data-json",
+		Usage: `The x509 certificate template data <json>, an inline JSON map of data that can be
+used by the certificate template. Cannot be used with **--x509-template-data**.`,
 	}
 	sshTemplateFlag = cli.StringFlag{
-		Name:  "ssh-template",
-		Usage: `The x509 certificate template <file>, a JSON representation of the certificate to create.`,
+		Name: "ssh-template",
+		Usage: `The x509 certificate template <file>, a JSON representation of the certificate to create.
+The <file> can also be an https URL.`,
 	}
 	sshTemplateDataFlag = cli.StringFlag{
-		Name:  "ssh-template-data",
-		Usage: `The ssh certificate template data <file>, a JSON map of data that can be used by the certificate template.`,
+		Name: "ssh-template-data",
+		Usage: `The ssh certificate template data <file>, a JSON map of data that can be used by the certificate template.
+The <file> can also be an https URL.`,
+	}
+	sshTemplateDataJSONFlag = cli.StringFlag{
+		Name: "ssh-template-data-json",
+		Usage: `The ssh certificate template data <json>, an inline JSON map of data that can be
+used by the certificate template. Cannot be used with **--ssh-template-data**.`,
+	}
+	expandEnvFlag = cli.BoolFlag{
+		Name: "expand-env",
+		Usage: `Replace the references to environment variables in the template data, written
+as ${VAR}, with their values. It fails if a referenced variable is not set.`,
+	}
+	insecureTemplateFlag = cli.BoolFlag{
+		Name: "insecure",
+		Usage: `Allow templates, template data and the OpenID Connect configuration endpoint
+to be retrieved from http URLs.`,
 	}
 	x509MinDurFlag = cli.StringFlag{
 		Name:  "x509-min-dur",
@@ -144,7 +718,7 @@ var (
 	}
 	sshUserDefaultDurFlag = cli.StringFlag{
 		Name:  "ssh-user-default-dur",
-		Usage: `The maximum <duration> for an ssh user certificate generated by this provisioner.`,
+		Usage: `The default <duration> for an ssh user certificate generated by this provisioner.`,
 	}
 	sshHostMinDurFlag = cli.StringFlag{
 		Name:  "ssh-host-min-dur",
@@ -156,7 +730,7 @@ var (
 	}
 	sshHostDefaultDurFlag = cli.StringFlag{
 		Name:  "ssh-host-default-dur",
-		Usage: `The maximum <duration> for an ssh host certificate generated by this provisioner.`,
+		Usage: `The default <duration> for an ssh host certificate generated by this provisioner.`,
 	}
 	disableRenewalFlag = cli.BoolFlag{
 		Name:  "disable-renewal",
@@ -166,6 +740,29 @@ var (
 		Name:  "allow-renewal-after-expiry",
 		Usage: `Allow renewals for expired certificates generated by this provisioner.`,
 	}
+	waitFlag = cli.BoolFlag{
+		Name: "wait",
+		Usage: `Wait until the change is visible in the CA before returning. The provisioner is
+requested every **--wait-interval** until it matches the change or
+**--wait-timeout** elapses. Useful in CA deployments with multiple instances.`,
+	}
+	waitIntervalFlag = cli.DurationFlag{
+		Name:  "wait-interval",
+		Usage: `The <duration> between two requests when **--wait** is used.`,
+		Value: time.Second,
+	}
+	waitTimeoutFlag = cli.DurationFlag{
+		Name:  "wait-timeout",
+		Usage: `The maximum <duration> to wait for the change when **--wait** is used.`,
+		Value: 30 * time.Second,
+	}
+	dryRunFlag = cli.BoolFlag{
+		Name: "dry-run",
+		Usage: `Validate the flags and print the resulting provisioner without
+modifying the CA configuration. The backend that would be used, the admin API
+of the CA in **--ca-url**, is printed to STDERR. Whether the admin API is
+enabled is not checked, as it requires a request to the CA.`,
+	}
 	enableX509Flag = cli.BoolFlag{
 		Name:  "x509",
 		Usage: `Enable provisioning of x509 certificates.`,
@@ -174,6 +771,12 @@ var (
 		Name:  "ssh",
 		Usage: `Enable provisioning of ssh certificates.`,
 	}
+	enableSSHCAFlag = cli.StringFlag{
+		Name: "enable-ssh-ca",
+		Usage: `Set whether the provisioner can issue ssh certificates. <value> must be "true"
+or "false". Unlike **--ssh**, it does not require any other ssh flag, and it keeps
+the existing ssh durations. If not set, the current value is preserved on update.`,
+	}
 	forceCNFlag = cli.BoolFlag{
 		Name:  "force-cn",
 		Usage: `Always set the common name in provisioned certificates.`,
@@ -201,18 +804,19 @@ var (
 		Usage: `Include the CA root certificate in the SCEP CA certificate chain`,
 	}
 	scepMinimumPublicKeyLengthFlag = cli.IntFlag{
-		Name:  "min-public-key-length",
-		Usage: `The minimum public key <length> of the SCEP RSA encryption key`,
+		Name: "min-public-key-length",
+		Usage: `The minimum public key <length> of the SCEP RSA encryption key. The <length>
+must be one of 1024, 2048, 3072 or 4096.`,
 	}
-	scepEncryptionAlgorithmIdentifierFlag = cli.IntFlag{
+	scepEncryptionAlgorithmIdentifierFlag = cli.StringFlag{
 		Name: "encryption-algorithm-identifier",
-		Usage: `The <id> for the SCEP encryption algorithm to use.
-		Valid values are 0 - 4, inclusive. The values correspond to:
-		0: DES-CBC, 
-		1: AES-128-CBC,
-		2: AES-256-CBC, 
-		3: AES-128-GCM, 
-		4: AES-256-GCM. 
+		Usage: `The <id> for the SCEP encryption algorithm to use. The <id> can be the
+number or the name of the algorithm:
+		0: des-cbc,
+		1: aes-128-cbc,
+		2: aes-256-cbc,
+		3: aes-128-gcm,
+		4: aes-256-gcm.
 		Defaults to DES-CBC (0) for legacy clients.`,
 	}
 
@@ -225,12 +829,19 @@ Use the flag multiple times to configure multiple accounts.`,
 	removeAWSAccountFlag = cli.StringSliceFlag{
 		Name: "remove-aws-account",
 		Usage: `Remove an AWS account <id> used to validate the identity documents.
-Use the flag multiple times to remove multiple accounts.`,
+Use the flag multiple times to remove multiple accounts.
+Use "*" to remove all of them. An empty list does not restrict the accounts, and the
+provisioner accepts the identity documents of any account.`,
 	}
 	azureTenantFlag = cli.StringFlag{
 		Name:  "azure-tenant",
 		Usage: `The Microsoft Azure tenant <id> used to validate the identity tokens.`,
 	}
+	azureAudienceFlag = cli.StringFlag{
+		Name: "azure-audience",
+		Usage: `The Microsoft Azure identity token audience <uri>. Required by some Azure clouds.
+Defaults to https://management.azure.com/ if not set.`,
+	}
 	azureResourceGroupFlag = cli.StringSliceFlag{
 		Name: "azure-resource-group",
 		Usage: `The Microsoft Azure resource group <name> used to validate the identity tokens.
@@ -239,7 +850,9 @@ Use the flag multiple times to configure multiple resource groups`,
 	removeAzureResourceGroupFlag = cli.StringSliceFlag{
 		Name: "remove-azure-resource-group",
 		Usage: `Remove a Microsoft Azure resource group <name> used to validate the identity tokens.
-Use the flag multiple times to configure multiple resource groups`,
+Use the flag multiple times to configure multiple resource groups.
+Use "*" to remove all of them. An empty list does not restrict the resource groups, and the
+provisioner accepts the identity tokens of any resource group.`,
 	}
 	azureSubscriptionIDFlag = cli.StringSliceFlag{
 		Name: "azure-subscription-id",
@@ -249,7 +862,9 @@ Use the flag multiple times to configure multiple subscription IDs`,
 	removeAzureSubscriptionIDFlag = cli.StringSliceFlag{
 		Name: "remove-azure-subscription-id",
 		Usage: `Remove a Microsoft Azure subscription <id> used to validate the identity tokens.
-Use the flag multiple times to configure multiple subscription IDs`,
+Use the flag multiple times to configure multiple subscription IDs.
+Use "*" to remove all of them. An empty list does not restrict the subscriptions, and the
+provisioner accepts the identity tokens of any subscription.`,
 	}
 	azureObjectIDFlag = cli.StringSliceFlag{
 		Name: "azure-object-id",
@@ -259,7 +874,9 @@ Use the flag multiple times to configure multiple object IDs`,
 	removeAzureObjectIDFlag = cli.StringSliceFlag{
 		Name: "remove-azure-object-id",
 		Usage: `Remove a Microsoft Azure AD object <id> used to validate the identity tokens.
-Use the flag multiple times to configure multiple object IDs`,
+Use the flag multiple times to configure multiple object IDs.
+Use "*" to remove all of them. An empty list does not restrict the objects, and the
+provisioner accepts the identity tokens of any object.`,
 	}
 	gcpServiceAccountFlag = cli.StringSliceFlag{
 		Name: "gcp-service-account",
@@ -269,7 +886,9 @@ Use the flag multiple times to configure multiple service accounts.`,
 	removeGCPServiceAccountFlag = cli.StringSliceFlag{
 		Name: "remove-gcp-service-account",
 		Usage: `Remove a Google service account <email> or <id> used to validate the identity tokens.
-Use the flag multiple times to configure multiple service accounts.`,
+Use the flag multiple times to configure multiple service accounts.
+Use "*" to remove all of them. An empty list does not restrict the service accounts, and the
+provisioner accepts the identity tokens of any service account.`,
 	}
 	gcpProjectFlag = cli.StringSliceFlag{
 		Name: "gcp-project",
@@ -279,69 +898,355 @@ Use the flag multiple times to configure multiple projects`,
 	removeGCPProjectFlag = cli.StringSliceFlag{
 		Name: "remove-gcp-project",
 		Usage: `Remove a Google project <id> used to validate the identity tokens.
-Use the flag multiple times to configure multiple projects`,
+Use the flag multiple times to configure multiple projects.
+Use "*" to remove all of them. An empty list does not restrict the projects, and the
+provisioner accepts the identity tokens of any project.`,
 	}
-	instanceAgeFlag = cli.DurationFlag{
+	instanceAgeFlag = cli.StringFlag{
 		Name: "instance-age",
 		Usage: `The maximum <duration> to grant a certificate in AWS and GCP provisioners.
 A <duration> is sequence of decimal numbers, each with optional fraction and a
-unit suffix, such as "300ms", "-1.5h" or "2h45m". Valid time units are "ns",
-"us" (or "µs"), "ms", "s", "m", "h".`,
+unit suffix, such as "300ms", "1.5h", "7d" or "1d12h". Valid time units are "ns",
+"us" (or "µs"), "ms", "s", "m", "h", "d" and "w". Use "0s" to remove the instance age.`,
+	}
+	instanceAgeWarningFlag = cli.DurationFlag{
+		Name:  "instance-age-warning",
+		Value: 168 * time.Hour,
+		Usage: `Print a warning if the **--instance-age** is greater than the given <duration>.
+Use "0s" to disable the warning.`,
 	}
 	iidRootsFlag = cli.StringFlag{
 		Name: "iid-roots",
 		Usage: `The <file> containing the certificates used to validate the
 instance identity documents in AWS.`,
+	}
+	oidcConfigurationSnapshotFlag = cli.StringFlag{
+		Name: "configuration-snapshot",
+		Usage: `Write the OpenID Connect discovery document retrieved from the
+**--configuration-endpoint** to <file>, e.g. to review it later.`,
 	}
 	disableCustomSANsFlag = cli.BoolFlag{
 		Name: "disable-custom-sans",
 		Usage: `On cloud provisioners, if enabled only the internal DNS and IP will be added as a SAN.
-By default it will accept any SAN in the CSR.`,
+By default it will accept any SAN in the CSR. Only supported by AWS, Azure and
+GCP provisioners.`,
 	}
 	disableTOFUFlag = cli.BoolFlag{
 		Name: "disable-trust-on-first-use,disable-tofu",
 		Usage: `On cloud provisioners, if enabled multiple sign request for this provisioner
 with the same instance will be accepted. By default only the first request
-will be accepted.`,
+will be accepted. Only supported by AWS, Azure and GCP provisioners.`,
 	}
 
 	// Nebula provisioner flags
-	nebulaRootFlag = cli.StringFlag{
+	x5cRootFlag = cli.StringSliceFlag{
+		Name: "x5c-root",
+		Usage: `Root certificate (chain) <file> used to validate the signature on X5C
+provisioning tokens. Use the flag multiple times to trust the CA certificates
+in multiple files.`,
+	}
+	pemKeysFlag = cli.StringSliceFlag{
+		Name: "pem-keys",
+		Usage: `The <file> containing one or more PEM formatted public keys used to validate
+Kubernetes service account tokens. Use the flag multiple times to trust the keys
+in multiple files. Requires the K8SSA provisioner.`,
+	}
+	nebulaRootFlag = cli.StringSliceFlag{
 		Name: "nebula-root",
 		Usage: `Root certificate (chain) <file> used to validate the signature on Nebula
-provisioning tokens.`,
+provisioning tokens. Use the flag multiple times to trust the CA certificates
+in multiple files.`,
 	}
 )
 
-func readNebulaRoots(rootFile string) ([][]byte, error) {
-	b, err := utils.ReadFile(rootFile)
-	if err != nil {
-		return nil, err
+// readNebulaRoots returns the PEM encoded Nebula CA certificates in the given
+// files. Duplicated certificates are only included once.
+func readNebulaRoots(rootFiles []string) ([][]byte, error) {
+	var rootBytes [][]byte
+	var total int
+	seen := make(map[string]bool)
+	now := time.Now()
+	for _, rootFile := range rootFiles {
+		b, err := utils.ReadFile(rootFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var crt *nebula.NebulaCertificate
+		var n, nonCA int
+		for len(b) > 0 {
+			crt, b, err = nebula.UnmarshalNebulaCertificateFromPEM(b)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error reading %s", rootFile)
+			}
+			n++
+			if !crt.Details.IsCA {
+				nonCA++
+				continue
+			}
+			if crt.Expired(now) {
+				ui.Printf("Warning: the Nebula CA certificate %s in %s is expired or not yet valid.\n", crt.Details.Name, rootFile)
+			}
+			var pemBytes []byte
+			pemBytes, err = crt.MarshalToPEM()
+			if err != nil {
+				return nil, errors.Wrap(err, "error marshaling certificate")
+			}
+			if !seen[string(pemBytes)] {
+				seen[string(pemBytes)] = true
+				rootBytes = append(rootBytes, pemBytes)
+			}
+		}
+		if n == 0 {
+			ui.Printf("Warning: no certificates found in %s.\n", rootFile)
+		}
+		if nonCA > 0 {
+			ui.Printf("Warning: skipped %d non-CA certificates in %s.\n", nonCA, rootFile)
+		}
+		total += n
+	}
+
+	files := strings.Join(rootFiles, ", ")
+	switch {
+	case total == 0:
+		return nil, errors.Errorf("error reading %s: no certificates found", files)
+	case len(rootBytes) == 0:
+		return nil, errors.Errorf("error reading %s: no CA certificates found", files)
 	}
 
-	var crt *nebula.NebulaCertificate
-	var certs []*nebula.NebulaCertificate
-	for len(b) > 0 {
-		crt, b, err = nebula.UnmarshalNebulaCertificateFromPEM(b)
+	return rootBytes, nil
+}
+
+// readX5CRoots returns the PEM encoded X5C root certificates in the given
+// files. All the certificates must have the 'Certificate Sign' key usage, and
+// duplicated certificates are only included once.
+func readX5CRoots(rootFiles []string) ([][]byte, error) {
+	var rootBytes [][]byte
+	seen := make(map[string]bool)
+	for _, rootFile := range rootFiles {
+		roots, err := pemutil.ReadCertificateBundle(rootFile)
 		if err != nil {
-			return nil, errors.Wrapf(err, "error reading %s", rootFile)
+			return nil, errors.Wrapf(err, "error loading X5C Root certificates from %s", rootFile)
 		}
-		if crt.Details.IsCA {
-			certs = append(certs, crt)
+		for _, r := range roots {
+			if r.KeyUsage&x509.KeyUsageCertSign == 0 {
+				return nil, errors.Errorf("error: certificate with common name '%s' in %s cannot be "+
+					"used as an X5C root certificate.\n\n"+
+					"X5C provisioner root certificates must have the 'Certificate Sign' key "+
+					"usage extension.", r.Subject.CommonName, rootFile)
+			}
+			if !seen[string(r.Raw)] {
+				seen[string(r.Raw)] = true
+				rootBytes = append(rootBytes, pem.EncodeToMemory(&pem.Block{
+					Type:  "CERTIFICATE",
+					Bytes: r.Raw,
+				}))
+			}
 		}
 	}
-	if len(certs) == 0 {
-		return nil, errors.Errorf("error reading %s: no CA certificates found", rootFile)
+
+	if len(rootBytes) == 0 {
+		return nil, errors.Errorf("error reading %s: no CA certificates found", strings.Join(rootFiles, ", "))
+	}
+	return rootBytes, nil
+}
+
+// k8sSAKeyFiles returns the files with the public keys of a K8SSA provisioner.
+// The keys can be set with --pem-keys, or with --public-key for backwards
+// compatibility.
+func k8sSAKeyFiles(ctx *cli.Context) ([]string, error) {
+	if ctx.IsSet("pem-keys") && ctx.IsSet("public-key") {
+		return nil, errs.MutuallyExclusiveFlags(ctx, "pem-keys", "public-key")
+	}
+	if ctx.IsSet("public-key") {
+		return []string{ctx.String("public-key")}, nil
 	}
+	return ctx.StringSlice("pem-keys"), nil
+}
 
-	rootBytes := make([][]byte, len(certs))
-	for i, crt := range certs {
-		b, err = crt.MarshalToPEM()
+// readK8SSAPublicKeys returns the PEM encoded RSA, ECDSA and Ed25519 public
+// keys in the given files. Duplicated keys are only included once.
+func readK8SSAPublicKeys(keyFiles []string) ([][]byte, error) {
+	var pubKeyBytes [][]byte
+	seen := make(map[string]bool)
+	for _, keyFile := range keyFiles {
+		b, err := utils.ReadFile(keyFile)
 		if err != nil {
-			return nil, errors.Wrap(err, "error marshaling certificate")
+			return nil, err
+		}
+
+		var block *pem.Block
+		for {
+			block, b = pem.Decode(b)
+			if block == nil {
+				break
+			}
+			if strings.Contains(block.Type, "PRIVATE KEY") {
+				return nil, errors.Errorf("error reading %s: found a private key, only public keys are allowed", keyFile)
+			}
+			var key interface{}
+			if key, err = pemutil.ParseKey(pem.EncodeToMemory(block)); err != nil {
+				return nil, errors.Wrapf(err, "error parsing public key from %s", keyFile)
+			}
+			switch key.(type) {
+			case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+			default:
+				return nil, errors.Errorf("error reading %s: unexpected public key type %T", keyFile, key)
+			}
+			var blk *pem.Block
+			if blk, err = pemutil.Serialize(key); err != nil {
+				return nil, errors.Wrap(err, "error serializing pem key")
+			}
+			if pemBytes := pem.EncodeToMemory(blk); !seen[string(pemBytes)] {
+				seen[string(pemBytes)] = true
+				pubKeyBytes = append(pubKeyBytes, pemBytes)
+			}
 		}
-		rootBytes[i] = b
 	}
 
-	return rootBytes, nil
+	if len(pubKeyBytes) == 0 {
+		return nil, errors.Errorf("error reading %s: no public keys found", strings.Join(keyFiles, ", "))
+	}
+	return pubKeyBytes, nil
+}
+
+// readTemplateData returns the template data set using the file flag with the
+// given name, or the inline JSON passed using its "-json" counterpart.
+func readTemplateData(ctx *cli.Context, name string) ([]byte, error) {
+	jsonName := name + "-json"
+	if ctx.IsSet(name) && ctx.IsSet(jsonName) {
+		return nil, errs.MutuallyExclusiveFlags(ctx, name, jsonName)
+	}
+	if ctx.IsSet(jsonName) {
+		data := ctx.String(jsonName)
+		if data == "" {
+			return nil, nil
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &m); err != nil || m == nil {
+			return nil, errs.InvalidFlagValueMsg(ctx, jsonName, data, "value must be a JSON object")
+		}
+		if ctx.Bool("expand-env") {
+			return expandTemplateDataEnv(m)
+		}
+		return []byte(data), nil
+	}
+	if filename := ctx.String(name); filename != "" {
+		b, err := readTemplateSource(ctx, filename)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil || m == nil {
+			return nil, errors.Errorf("error reading %s: template data must be a JSON object", filename)
+		}
+		if ctx.Bool("expand-env") {
+			b, err = expandTemplateDataEnv(m)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error reading %s", filename)
+			}
+		}
+		return b, nil
+	}
+	return nil, nil
+}
+
+// templateFetchTimeout is the timeout used to retrieve templates from a URL.
+var templateFetchTimeout = 15 * time.Second
+
+// readTemplate returns the template in the file or URL set using the flag
+// with the given name, after checking that it can be parsed.
+func readTemplate(ctx *cli.Context, name string) ([]byte, error) {
+	filename := ctx.String(name)
+	b, err := readTemplateSource(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	funcMap := sprig.TxtFuncMap()
+	funcMap["fail"] = func(string) (string, error) { return "", nil }
+	if _, err := template.New(name).Funcs(funcMap).Parse(string(b)); err != nil {
+		return nil, errors.Wrapf(err, "error parsing %s", filename)
+	}
+	return b, nil
+}
+
+// readTemplateSource reads the given file, or retrieves it if it is an https
+// URL. Plain http URLs are only allowed with the --insecure flag.
+func readTemplateSource(ctx *cli.Context, filename string) ([]byte, error) {
+	u, err := url.Parse(filename)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return utils.ReadFile(filename)
+	}
+	if u.Scheme == "http" && !ctx.Bool("insecure") {
+		return nil, errors.Errorf("error retrieving %s: http URLs require the '--insecure' flag", filename)
+	}
+
+	client := &http.Client{Timeout: templateFetchTimeout}
+	resp, err := client.Get(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving %s", filename)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("error retrieving %s: status code %d", filename, resp.StatusCode)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving %s", filename)
+	}
+	return b, nil
+}
+
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandTemplateDataEnv replaces the ${VAR} references in all the string
+// values of the given template data, including nested ones, with the value of
+// the environment variable VAR, and returns the data encoded in JSON. It
+// fails if any of the variables is not set.
+func expandTemplateDataEnv(data map[string]interface{}) ([]byte, error) {
+	v, err := expandEnv(data)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling template data")
+	}
+	return b, nil
+}
+
+func expandEnv(v interface{}) (interface{}, error) {
+	var err error
+	switch v := v.(type) {
+	case string:
+		var missing string
+		s := envVarRegexp.ReplaceAllStringFunc(v, func(ref string) string {
+			name := envVarRegexp.FindStringSubmatch(ref)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok && missing == "" {
+				missing = name
+			}
+			return value
+		})
+		if missing != "" {
+			return nil, errors.Errorf("environment variable %s is not set", missing)
+		}
+		return s, nil
+	case map[string]interface{}:
+		for k, e := range v {
+			if v[k], err = expandEnv(e); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	case []interface{}:
+		for i, e := range v {
+			if v[i], err = expandEnv(e); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
 }
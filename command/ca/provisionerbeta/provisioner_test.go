@@ -0,0 +1,409 @@
+package provisionerbeta
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nebula "github.com/slackhq/nebula/cert"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/crypto/pemutil"
+	"github.com/smallstep/cli/flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRemoveElements(t *testing.T) {
+	tests := []struct {
+		name string
+		list []string
+		rems []string
+		want []string
+	}{
+		{"nil list", nil, []string{"a"}, nil},
+		{"empty list", []string{}, []string{"a"}, []string{}},
+		{"empty rems", []string{"a", "b"}, nil, []string{"a", "b"}},
+		{"remove first", []string{"a", "b", "c"}, []string{"a"}, []string{"b", "c"}},
+		{"remove middle", []string{"a", "b", "c"}, []string{"b"}, []string{"a", "c"}},
+		{"remove last", []string{"a", "b", "c"}, []string{"c"}, []string{"a", "b"}},
+		{"remove many", []string{"a", "b", "c", "d"}, []string{"d", "a"}, []string{"b", "c"}},
+		{"remove all", []string{"a", "b"}, []string{"a", "b"}, []string{}},
+		{"duplicates", []string{"a", "b", "a", "c", "a"}, []string{"a"}, []string{"b", "c"}},
+		{"duplicate rems", []string{"a", "b", "c"}, []string{"b", "b"}, []string{"a", "c"}},
+		{"not present", []string{"a", "b"}, []string{"c"}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, removeElements(tt.list, tt.rems))
+		})
+	}
+}
+
+func TestRemoveCloudElements(t *testing.T) {
+	assert.Equal(t, []string{"b"}, removeCloudElements([]string{"a", "b"}, []string{"a"}))
+	assert.Equal(t, []string{}, removeCloudElements([]string{"a", "b"}, []string{"*"}))
+	assert.Equal(t, []string{}, removeCloudElements([]string{"a", "b"}, []string{"c", "*"}))
+	assert.Equal(t, []string{}, removeCloudElements(nil, []string{"*"}))
+}
+
+func mustNebulaCertificate(t *testing.T, name string, isCA bool) []byte {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	crt := &nebula.NebulaCertificate{
+		Details: nebula.NebulaCertificateDetails{
+			Name:      name,
+			NotBefore: time.Now().Add(-time.Minute),
+			NotAfter:  time.Now().Add(time.Hour),
+			PublicKey: pub,
+			IsCA:      isCA,
+		},
+	}
+	require.NoError(t, crt.Sign(priv))
+	b, err := crt.MarshalToPEM()
+	require.NoError(t, err)
+	return b
+}
+
+func TestReadNebulaRoots(t *testing.T) {
+	dir := t.TempDir()
+	root1 := mustNebulaCertificate(t, "root1", true)
+	root2 := mustNebulaCertificate(t, "root2", true)
+	leaf := mustNebulaCertificate(t, "leaf", false)
+
+	writeFile := func(name string, data ...[]byte) string {
+		var b []byte
+		for _, d := range data {
+			b = append(b, d...)
+		}
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, b, 0600))
+		return filename
+	}
+	file1 := writeFile("root1.crt", root1)
+	file2 := writeFile("root2.crt", root2)
+	bundle := writeFile("bundle.crt", root1, root2)
+	leafFile := writeFile("leaf.crt", leaf)
+	emptyFile := writeFile("empty.crt")
+
+	tests := []struct {
+		name    string
+		files   []string
+		want    [][]byte
+		wantErr bool
+	}{
+		{"one file", []string{file1}, [][]byte{root1}, false},
+		{"two files", []string{file1, file2}, [][]byte{root1, root2}, false},
+		{"deduplicate", []string{file1, bundle, file2}, [][]byte{root1, root2}, false},
+		{"skip non-CA", []string{leafFile, file2}, [][]byte{root2}, false},
+		{"skip empty", []string{emptyFile, file1}, [][]byte{root1}, false},
+		{"only non-CA", []string{leafFile}, nil, true},
+		{"no certificates", []string{emptyFile}, nil, true},
+		{"missing file", []string{file1, filepath.Join(dir, "missing.crt")}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readNebulaRoots(tt.files)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func mustX509Certificate(t *testing.T, name string, isCA bool) []byte {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestReadX5CRoots(t *testing.T) {
+	dir := t.TempDir()
+	root1 := mustX509Certificate(t, "root1", true)
+	root2 := mustX509Certificate(t, "root2", true)
+	leaf := mustX509Certificate(t, "leaf", false)
+
+	writeFile := func(name string, data ...[]byte) string {
+		var b []byte
+		for _, d := range data {
+			b = append(b, d...)
+		}
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, b, 0600))
+		return filename
+	}
+	file1 := writeFile("root1.crt", root1)
+	file2 := writeFile("root2.crt", root2)
+	bundle := writeFile("bundle.crt", root1, root2)
+	leafFile := writeFile("leaf.crt", leaf)
+	emptyFile := writeFile("empty.crt")
+
+	tests := []struct {
+		name    string
+		files   []string
+		want    [][]byte
+		wantErr bool
+	}{
+		{"one file", []string{file1}, [][]byte{root1}, false},
+		{"two files", []string{file1, file2}, [][]byte{root1, root2}, false},
+		{"deduplicate", []string{file1, bundle, file2}, [][]byte{root1, root2}, false},
+		{"non-CA", []string{file1, leafFile}, nil, true},
+		{"no certificates", []string{emptyFile}, nil, true},
+		{"missing file", []string{file1, filepath.Join(dir, "missing.crt")}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readX5CRoots(tt.files)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadK8SSAPublicKeys(t *testing.T) {
+	dir := t.TempDir()
+	mustPublicKey := func() ([]byte, []byte) {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		pubBlock, err := pemutil.Serialize(pub)
+		require.NoError(t, err)
+		privBlock, err := pemutil.Serialize(priv)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(pubBlock), pem.EncodeToMemory(privBlock)
+	}
+	key1, priv1 := mustPublicKey()
+	key2, _ := mustPublicKey()
+
+	writeFile := func(name string, data ...[]byte) string {
+		var b []byte
+		for _, d := range data {
+			b = append(b, d...)
+		}
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, b, 0600))
+		return filename
+	}
+	file1 := writeFile("key1.pub", key1)
+	file2 := writeFile("key2.pub", key2)
+	bundle := writeFile("bundle.pub", key1, key2)
+	privFile := writeFile("key1.key", priv1)
+	emptyFile := writeFile("empty.pub")
+
+	tests := []struct {
+		name    string
+		files   []string
+		want    [][]byte
+		wantErr bool
+	}{
+		{"one file", []string{file1}, [][]byte{key1}, false},
+		{"two files", []string{file1, file2}, [][]byte{key1, key2}, false},
+		{"deduplicate", []string{file1, bundle, file2}, [][]byte{key1, key2}, false},
+		{"private key", []string{file1, privFile}, nil, true},
+		{"no keys", []string{emptyFile}, nil, true},
+		{"missing file", []string{file1, filepath.Join(dir, "missing.pub")}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readK8SSAPublicKeys(tt.files)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadTemplateData_expandEnv(t *testing.T) {
+	t.Setenv("STEP_TEST_ORG", "Smallstep")
+	t.Setenv("STEP_TEST_SECRET", `s3cr3t"`)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "data.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{
+		"organization": "${STEP_TEST_ORG} Labs",
+		"nested": {"secret": "${STEP_TEST_SECRET}", "list": ["$STEP_TEST_ORG", "${STEP_TEST_ORG}", 1]},
+		"number": 42
+	}`), 0600))
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"file", []string{"--x509-template-data", filename, "--expand-env"},
+			`{"nested":{"list":["$STEP_TEST_ORG","Smallstep",1],"secret":"s3cr3t\""},"number":42,"organization":"Smallstep Labs"}`, false},
+		{"inline", []string{"--x509-template-data-json", `{"a": {"b": "${STEP_TEST_ORG}"}}`, "--expand-env"},
+			`{"a":{"b":"Smallstep"}}`, false},
+		{"disabled", []string{"--x509-template-data-json", `{"a": "${STEP_TEST_ORG}"}`},
+			`{"a": "${STEP_TEST_ORG}"}`, false},
+		{"missing variable", []string{"--x509-template-data-json", `{"a": {"b": ["${STEP_TEST_MISSING}"]}}`, "--expand-env"},
+			"", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, templateTestCommand().Flags, tt.args)
+			got, err := readTemplateData(ctx, "x509-template-data")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestValidateCloudFlags(t *testing.T) {
+	flags := []cli.Flag{disableCustomSANsFlag, disableTOFUFlag}
+	tests := []struct {
+		name    string
+		typ     linkedca.Provisioner_Type
+		args    []string
+		wantErr string
+	}{
+		{"aws", linkedca.Provisioner_AWS, []string{"--disable-custom-sans", "--disable-trust-on-first-use"}, ""},
+		{"azure", linkedca.Provisioner_AZURE, []string{"--disable-custom-sans"}, ""},
+		{"gcp", linkedca.Provisioner_GCP, []string{"--disable-trust-on-first-use"}, ""},
+		{"jwk no flags", linkedca.Provisioner_JWK, nil, ""},
+		{"fail jwk custom sans", linkedca.Provisioner_JWK, []string{"--disable-custom-sans"}, "flag '--disable-custom-sans' cannot be used with JWK provisioners"},
+		{"fail acme tofu", linkedca.Provisioner_ACME, []string{"--disable-trust-on-first-use=false"}, "flag '--disable-trust-on-first-use' cannot be used with ACME provisioners"},
+		{"fail x5c", linkedca.Provisioner_X5C, []string{"--disable-custom-sans", "--disable-trust-on-first-use"}, "flag '--disable-custom-sans' cannot be used with X5C provisioners"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, flags, tt.args)
+			err := validateCloudFlags(ctx, tt.typ)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseInstanceAge(t *testing.T) {
+	flags := []cli.Flag{instanceAgeFlag, instanceAgeWarningFlag}
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"1h", "1h", false},
+		{"7d", "168h0m0s", false},
+		{"2w", "336h0m0s", false},
+		{"1d12h", "36h0m0s", false},
+		{"1.5d", "36h0m0s", false},
+		{"0d", "", false},
+		{"3y", "", true},
+		{"1dd", "", true},
+		{"d", "", true},
+		{"-1d", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ctx := newTestContext(t, flags, []string{"--instance-age", tt.value, "--instance-age-warning", "0s"})
+			got, err := parseInstanceAge(ctx)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// stubGetter is a provisionerGetter returning the given provisioners, or a
+// not found error for nil ones.
+type stubGetter struct {
+	responses []*linkedca.Provisioner
+	calls     int
+}
+
+func (g *stubGetter) GetProvisioner(opts ...ca.ProvisionerOption) (*linkedca.Provisioner, error) {
+	i := g.calls
+	if i >= len(g.responses) {
+		i = len(g.responses) - 1
+	}
+	g.calls++
+	if g.responses[i] == nil {
+		return nil, &ca.AdminClientError{Type: "notFound", Message: "provisioner not found"}
+	}
+	return g.responses[i], nil
+}
+
+func TestWaitForProvisioner(t *testing.T) {
+	old := &linkedca.Provisioner{Id: "id", Name: "acme", Type: linkedca.Provisioner_ACME,
+		Claims: &linkedca.Claims{DisableRenewal: false}}
+	want := proto.Clone(old).(*linkedca.Provisioner)
+	want.Claims.DisableRenewal = true
+	// The CA can set defaults on fields not changed by the command.
+	visible := proto.Clone(want).(*linkedca.Provisioner)
+	visible.CreatedAt = timestamppb.Now()
+	visible.Claims.X509 = &linkedca.X509Claims{Enabled: true}
+	visible.Details = &linkedca.ProvisionerDetails{Data: &linkedca.ProvisionerDetails_ACME{ACME: &linkedca.ACMEProvisioner{}}}
+
+	client := &stubGetter{responses: []*linkedca.Provisioner{old, visible}}
+	require.NoError(t, waitForProvisioner(client, old, want, time.Millisecond, time.Second))
+	assert.Equal(t, 2, client.calls)
+
+	client = &stubGetter{responses: []*linkedca.Provisioner{old}}
+	err := waitForProvisioner(client, old, want, time.Millisecond, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "not visible after 10ms")
+
+	// New provisioners only need to exist with the same id.
+	client = &stubGetter{responses: []*linkedca.Provisioner{nil, visible}}
+	require.NoError(t, waitForProvisioner(client, nil, want, time.Millisecond, time.Second))
+	assert.Equal(t, 2, client.calls)
+
+	other := proto.Clone(visible).(*linkedca.Provisioner)
+	other.Id = "other-id"
+	client = &stubGetter{responses: []*linkedca.Provisioner{other}}
+	err = waitForProvisioner(client, nil, want, time.Millisecond, 10*time.Millisecond)
+	assert.ErrorContains(t, err, "not visible after 10ms")
+}
+
+func TestPrintBackend(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := newTestContext(t, []cli.Flag{flags.CaURL}, []string{"--ca-url", "ca.example.com"})
+	require.NoError(t, printBackend(ctx, &buf))
+	assert.Equal(t, "Backend: admin API at https://ca.example.com, no changes will be made.\n", buf.String())
+}
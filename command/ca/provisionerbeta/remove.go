@@ -1,11 +1,16 @@
 package provisionerbeta
 
 import (
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
 	"github.com/smallstep/certificates/ca"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
+	"go.step.sm/cli-utils/ui"
 )
 
 func removeCommand() cli.Command {
@@ -13,34 +18,84 @@ func removeCommand() cli.Command {
 		Name:   "remove",
 		Action: cli.ActionFunc(removeAction),
 		Usage:  "remove a provisioner from the CA configuration",
-		UsageText: `**step beta ca provisioner remove** <name>
+		UsageText: `**step beta ca provisioner remove** <name> [**--id**=<id>]
+[**--match**=<pattern>] [**--force**]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]`,
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
 		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name: "id",
+				Usage: `The <id> of the provisioner to remove. If a name is also given, the name is
+ignored.`,
+			},
+			cli.StringFlag{
+				Name: "match",
+				Usage: `Remove all the provisioners with a name matching the given glob <pattern>
+(e.g. "ci-pr-*"). The list of matching provisioners is printed and confirmation
+is requested before removing them.`,
+			},
+			cli.BoolFlag{
+				Name:  "force",
+				Usage: `Remove the provisioners matching **--match** without asking for confirmation.`,
+			},
 			flags.AdminCert,
 			flags.AdminKey,
 			flags.AdminProvisioner,
 			flags.AdminSubject,
 			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
 			flags.CaURL,
 			flags.Root,
 			flags.Context,
 		},
 		Description: `**step beta ca provisioner remove** removes a provisioner from the CA configuration.
 
+## EXIT CODES
+
+This command returns '0' on success, '3' if the provisioner does not exist, or
+no provisioners match **--match**, and '1' for any other error.
+
 ## EXAMPLES
 
 Remove provisioner by name:
 '''
 $ step beta ca provisioner remove acme
 '''
+
+Remove all provisioners with a name starting with "ci-pr-":
+'''
+$ step beta ca provisioner remove --match 'ci-pr-*'
+'''
+
+Remove provisioner by id:
+'''
+$ step beta ca provisioner remove --id 1a2b3c4d-5e6f-7a8b-9c0d-1e2f3a4b5c6d
+'''
 `,
 	}
 }
 
 func removeAction(ctx *cli.Context) (err error) {
-	if err := errs.NumberOfArguments(ctx, 1); err != nil {
+	if pattern := ctx.String("match"); pattern != "" {
+		if err := errs.NumberOfArguments(ctx, 0); err != nil {
+			return err
+		}
+		if ctx.IsSet("id") {
+			return errs.IncompatibleFlagWithFlag(ctx, "match", "id")
+		}
+		return removeMatchAction(ctx, pattern)
+	}
+
+	id := ctx.String("id")
+	if id != "" {
+		if err := errs.MinMaxNumberOfArguments(ctx, 0, 1); err != nil {
+			return err
+		}
+	} else if err := errs.NumberOfArguments(ctx, 1); err != nil {
 		return err
 	}
 
@@ -52,5 +107,62 @@ func removeAction(ctx *cli.Context) (err error) {
 		return err
 	}
 
-	return client.RemoveProvisioner(ca.WithProvisionerName(name))
+	if id != "" {
+		if name != "" {
+			ui.Printf("Flag '--id' is set, ignoring the provisioner name %s.\n", name)
+		}
+		return notFoundExitError(client.RemoveProvisioner(ca.WithProvisionerID(id)))
+	}
+
+	return notFoundExitError(client.RemoveProvisioner(ca.WithProvisionerName(name)))
+}
+
+// removeMatchAction removes all the provisioners with a name matching the
+// given pattern.
+func removeMatchAction(ctx *cli.Context, pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errs.InvalidFlagValueMsg(ctx, "match", pattern, err.Error())
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	provisioners, err := client.GetProvisioners()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, p := range provisioners {
+		if ok, _ := path.Match(pattern, p.GetName()); ok {
+			names = append(names, p.GetName())
+		}
+	}
+	if len(names) == 0 {
+		return errs.NewExitError(errors.Errorf("no provisioners matching %s found", pattern), notFoundExitCode)
+	}
+
+	ui.Printf("The following provisioners will be removed:\n")
+	for _, name := range names {
+		ui.Printf("  - %s\n", name)
+	}
+	if !ctx.Bool("force") {
+		ok, err := ui.PromptYesNo(fmt.Sprintf("Remove %d provisioners? [y/n]", len(names)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("operation canceled")
+		}
+	}
+
+	for _, name := range names {
+		if err := client.RemoveProvisioner(ca.WithProvisionerName(name)); err != nil {
+			return errors.Wrapf(err, "error removing provisioner %s", name)
+		}
+		ui.Printf("Provisioner %s removed.\n", name)
+	}
+	return nil
 }
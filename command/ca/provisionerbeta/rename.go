@@ -0,0 +1,105 @@
+package provisionerbeta
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+func renameCommand() cli.Command {
+	return cli.Command{
+		Name:   "rename",
+		Action: cli.ActionFunc(renameAction),
+		Usage:  "rename a provisioner in the CA configuration",
+		UsageText: `**step beta ca provisioner rename** <old-name> <new-name>
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step beta ca provisioner rename** changes the name of a provisioner in the
+CA configuration. Only the name is modified, the keys and the rest of the
+configuration of the provisioner are preserved.
+
+## POSITIONAL ARGUMENTS
+
+<old-name>
+: The current name of the provisioner.
+
+<new-name>
+: The new name of the provisioner. There must not be a provisioner with this
+name already.
+
+## EXIT CODES
+
+This command returns '0' on success, '3' if the provisioner does not exist, and
+'1' for any other error.
+
+## EXAMPLES
+
+Rename the provisioner "ci" to "ci-legacy":
+'''
+$ step beta ca provisioner rename ci ci-legacy
+'''
+`,
+	}
+}
+
+func renameAction(ctx *cli.Context) (err error) {
+	if err := errs.NumberOfArguments(ctx, 2); err != nil {
+		return err
+	}
+
+	args := ctx.Args()
+	oldName, newName := args.Get(0), args.Get(1)
+	if newName == "" {
+		return errors.New("the new provisioner name cannot be empty")
+	}
+	if oldName == newName {
+		return errs.EqualArguments(ctx, "old-name", "new-name")
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	p, err := client.GetProvisioner(ca.WithProvisionerName(oldName))
+	if err != nil {
+		return notFoundExitError(err)
+	}
+
+	exists, err := provisionerExists(client, newName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.Errorf("provisioner %s already exists", newName)
+	}
+
+	p.Name = newName
+	if err := client.UpdateProvisioner(oldName, p); err != nil {
+		return notFoundExitError(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Provisioner %s renamed to %s.\n", oldName, newName)
+	return nil
+}
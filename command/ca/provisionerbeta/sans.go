@@ -0,0 +1,225 @@
+package provisionerbeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+)
+
+var (
+	defaultSANFlag = cli.StringSliceFlag{
+		Name: "default-san",
+		Usage: `Add a <san> always included in the x509 certificates, useful for cloud
+provisioners with custom SANs disabled. It can be a DNS name, an IP address, an
+email address or a URI. Use the flag multiple times to add multiple SANs. A
+custom x509 template must add '.defaultSANs' to the SANs of the certificate.`,
+	}
+	removeDefaultSANFlag = cli.StringSliceFlag{
+		Name: "remove-default-san",
+		Usage: `Remove a default <san> from the provisioner. Use the flag multiple times to
+remove multiple SANs.`,
+	}
+)
+
+// defaultSANsKey is the key in the x509 template data with the default SANs.
+const defaultSANsKey = "defaultSANs"
+
+// defaultSANsTemplate is the default leaf template of the CA adding the
+// default SANs stored in the template data. It is used if the provisioner
+// does not have a custom x509 template.
+const defaultSANsTemplate = `{
+	"subject": {{ toJson .Subject }},
+	"sans": {{ toJson (concat .SANs .defaultSANs) }},
+{{- if typeIs "*rsa.PublicKey" .Insecure.CR.PublicKey }}
+	"keyUsage": ["keyEncipherment", "digitalSignature"],
+{{- else }}
+	"keyUsage": ["digitalSignature"],
+{{- end }}
+	"extKeyUsage": ["serverAuth", "clientAuth"]
+}`
+
+// defaultSAN is the representation of a SAN in the template data, it matches
+// the one used by the certificate templates.
+type defaultSAN struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// updateDefaultSANs adds and removes the default SANs of the provisioner
+// using the --default-san and --remove-default-san flags. The SANs are stored
+// in the x509 template data, and if the provisioner does not have an x509
+// template, a template including them is set.
+func updateDefaultSANs(ctx *cli.Context, p *linkedca.Provisioner) error {
+	if !ctx.IsSet("default-san") && !ctx.IsSet("remove-default-san") {
+		return nil
+	}
+	for _, v := range ctx.StringSlice("default-san") {
+		if _, err := sanType(v); err != nil {
+			return errs.InvalidFlagValueMsg(ctx, "default-san", v, err.Error())
+		}
+	}
+
+	if p.X509Template == nil {
+		p.X509Template = &linkedca.Template{}
+	}
+	data := make(map[string]interface{})
+	if len(p.X509Template.Data) > 0 {
+		if err := json.Unmarshal(p.X509Template.Data, &data); err != nil {
+			return errors.Wrap(err, "error parsing x509 template data")
+		}
+	}
+
+	values := defaultSANValues(data)
+	values = removeElements(values, ctx.StringSlice("remove-default-san"))
+	values = appendUniqueElements(values, ctx.StringSlice("default-san"))
+
+	if len(values) == 0 {
+		delete(data, defaultSANsKey)
+		if bytes.Equal(p.X509Template.Template, []byte(defaultSANsTemplate)) {
+			p.X509Template.Template = nil
+		}
+	} else {
+		sans := make([]defaultSAN, len(values))
+		for i, v := range values {
+			typ, err := sanType(v)
+			if err != nil {
+				return errors.Wrapf(err, "error parsing default SAN %s", v)
+			}
+			sans[i] = defaultSAN{Type: typ, Value: v}
+		}
+		data[defaultSANsKey] = sans
+		if len(p.X509Template.Template) == 0 {
+			p.X509Template.Template = []byte(defaultSANsTemplate)
+		}
+		if ctx.IsSet("default-san") && !usesDefaultSANs(p.X509Template) {
+			return errors.Errorf("the x509 template of provisioner %s does not use .%s: "+
+				"add them to the sans of the template or remove the custom template", p.Name, defaultSANsKey)
+		}
+	}
+
+	if len(data) == 0 {
+		p.X509Template.Data = nil
+		return nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling x509 template data")
+	}
+	p.X509Template.Data = b
+	return nil
+}
+
+// defaultSANValues returns the values of the default SANs in the given x509
+// template data.
+func defaultSANValues(data map[string]interface{}) []string {
+	var values []string
+	if b, err := json.Marshal(data[defaultSANsKey]); err == nil {
+		var sans []defaultSAN
+		if err := json.Unmarshal(b, &sans); err == nil {
+			for _, san := range sans {
+				values = append(values, san.Value)
+			}
+		}
+	}
+	return values
+}
+
+// templateDefaultSANs returns the values of the default SANs stored in the
+// data of the given x509 template.
+func templateDefaultSANs(t *linkedca.Template) []string {
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(t.GetData(), &data); err != nil {
+		return nil
+	}
+	return defaultSANValues(data)
+}
+
+// usesDefaultSANs returns true if the given x509 template references the
+// default SANs in the template data.
+func usesDefaultSANs(t *linkedca.Template) bool {
+	return bytes.Contains(t.GetTemplate(), []byte("."+defaultSANsKey))
+}
+
+// defaultSANsWarnings returns warnings about the default SANs of a provisioner
+// that will not be included in the certificates after an update, either
+// because the x509 template does not use them, or because they were removed
+// by replacing the x509 template data.
+func defaultSANsWarnings(ctx *cli.Context, old, p *linkedca.Provisioner) []string {
+	var warnings []string
+	if sans := templateDefaultSANs(p.X509Template); len(sans) > 0 && !usesDefaultSANs(p.X509Template) {
+		warnings = append(warnings, fmt.Sprintf("the x509 template does not use .%s, "+
+			"the default SANs %s will not be added to the certificates", defaultSANsKey, strings.Join(sans, ", ")))
+	}
+	lost := removeElements(templateDefaultSANs(old.X509Template), templateDefaultSANs(p.X509Template))
+	lost = removeElements(lost, ctx.StringSlice("remove-default-san"))
+	if len(lost) > 0 {
+		warnings = append(warnings, fmt.Sprintf("the default SANs %s have been removed with the x509 template data",
+			strings.Join(lost, ", ")))
+	}
+	return warnings
+}
+
+// sanType returns the type of the given SAN as used in the certificate
+// templates: "ip", "email", "uri" or "dns".
+func sanType(s string) (string, error) {
+	switch {
+	case net.ParseIP(s) != nil:
+		return "ip", nil
+	case strings.Contains(s, "://"):
+		if u, err := url.Parse(s); err != nil || u.Scheme == "" {
+			return "", errors.New("value must be a valid URI")
+		}
+		return "uri", nil
+	case strings.Contains(s, "@"):
+		if strings.HasPrefix(s, "@") || validateEmailPattern(s) != nil {
+			return "", errors.New("value must be a valid email address")
+		}
+		return "email", nil
+	default:
+		if err := validateDNSPattern(s); err != nil {
+			return "", errors.New("value must be a DNS name, an IP address, an email address or a URI")
+		}
+		return "dns", nil
+	}
+}
+
+// validateDNSPattern checks that the given value is a domain name, optionally
+// starting with a "*." wildcard.
+func validateDNSPattern(s string) error {
+	domain := strings.TrimPrefix(s, "*.")
+	if domain == "" || len(domain) > 253 {
+		return errors.New("value must be a domain name")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.New("value must be a domain name")
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return errors.New("value must be a domain name")
+			}
+		}
+	}
+	return nil
+}
+
+// validateEmailPattern checks that the given value is an email address, or a
+// domain name optionally starting with "@".
+func validateEmailPattern(s string) error {
+	i := strings.LastIndexByte(s, '@')
+	if i > 0 && strings.ContainsAny(s[:i], " @") {
+		return errors.New("value must be an email address or a domain")
+	}
+	if err := validateDNSPattern(s[i+1:]); err != nil || strings.HasPrefix(s[i+1:], "*.") {
+		return errors.New("value must be an email address or a domain")
+	}
+	return nil
+}
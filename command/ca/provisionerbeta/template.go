@@ -0,0 +1,156 @@
+package provisionerbeta
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/crypto/x509util"
+)
+
+func templateCommand() cli.Command {
+	return cli.Command{
+		Name:      "template",
+		Usage:     "work with provisioner certificate templates",
+		UsageText: "**step beta ca provisioner template** <subcommand> [arguments] [global-flags] [subcommand-flags]",
+		Subcommands: cli.Commands{
+			templateTestCommand(),
+		},
+		Description: `**step beta ca provisioner template** command group provides facilities for
+working with the certificate templates used by provisioners.
+
+## EXAMPLES
+
+Render an x509 template with the given data:
+'''
+$ step beta ca provisioner template test --x509-template leaf.tpl --x509-template-data data.json
+'''`,
+	}
+}
+
+func templateTestCommand() cli.Command {
+	return cli.Command{
+		Name:   "test",
+		Action: cli.ActionFunc(templateTestAction),
+		Usage:  "render a certificate template without using the CA",
+		UsageText: `**step beta ca provisioner template test** **--x509-template**=<file>
+[**--x509-template-data**=<file>] [**--x509-template-data-json**=<json>]
+[**--subject**=<subject>] [**--san**=<SAN>] [**--expand-env**] [**--insecure**]`,
+		Flags: []cli.Flag{
+			x509TemplateFlag,
+			x509TemplateDataFlag,
+			x509TemplateDataJSONFlag,
+			expandEnvFlag,
+			insecureTemplateFlag,
+			cli.StringFlag{
+				Name:  "subject",
+				Value: "example.com",
+				Usage: `The <subject> of the certificate request used to render the template.`,
+			},
+			cli.StringSliceFlag{
+				Name: "san",
+				Usage: `Add a DNS or IP Address Subjective Alternative Name (SAN) to the certificate
+request used to render the template. Use the '--san' flag multiple times to
+configure multiple SANs. Defaults to the subject.`,
+			},
+		},
+		Description: `**step beta ca provisioner template test** executes an x509 certificate
+template with the given template data and a certificate request generated from
+the **--subject** and **--san** flags, and prints the resulting certificate as
+JSON. The CA is not contacted.
+
+The template data is available in the template as in the CA. User data can be
+passed under the "Insecure.User" key of the template data.
+
+## EXAMPLES
+
+Render an x509 template:
+'''
+$ step beta ca provisioner template test --x509-template leaf.tpl
+'''
+
+Render an x509 template with template data and a custom certificate request:
+'''
+$ step beta ca provisioner template test --x509-template leaf.tpl \
+  --x509-template-data-json '{"organization": "Smallstep"}' \
+  --subject foo.internal --san foo.internal --san 10.0.0.1
+'''`,
+	}
+}
+
+func templateTestAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 0); err != nil {
+		return err
+	}
+
+	templateFile := ctx.String("x509-template")
+	if templateFile == "" {
+		return errs.RequiredFlag(ctx, "x509-template")
+	}
+	text, err := readTemplate(ctx, "x509-template")
+	if err != nil {
+		return err
+	}
+
+	subject := ctx.String("subject")
+	sans := ctx.StringSlice("san")
+	if len(sans) == 0 {
+		sans = []string{subject}
+	}
+	cr, err := newTemplateCertificateRequest(subject, sans)
+	if err != nil {
+		return err
+	}
+
+	data := x509util.CreateTemplateData(subject, sans)
+	data.SetCertificateRequest(cr)
+	b, err := readTemplateData(ctx, "x509-template-data")
+	if err != nil {
+		return err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &data); err != nil {
+			return errors.Wrap(err, "error parsing template data")
+		}
+	}
+
+	cert, err := x509util.NewCertificate(cr, x509util.WithTemplate(string(text), data))
+	if err != nil {
+		return errors.Wrapf(err, "error rendering %s", templateFile)
+	}
+
+	b, err = json.MarshalIndent(cert, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error marshaling certificate")
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// newTemplateCertificateRequest returns a certificate request with the given
+// subject and SANs, signed with a new key.
+func newTemplateCertificateRequest(subject string, sans []string) (*x509.CertificateRequest, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating key")
+	}
+	dnsNames, ips, emails, uris := x509util.SplitSANs(sans)
+	b, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: subject},
+		DNSNames:       dnsNames,
+		IPAddresses:    ips,
+		EmailAddresses: emails,
+		URIs:           uris,
+	}, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating certificate request")
+	}
+	return x509.ParseCertificateRequest(b)
+}
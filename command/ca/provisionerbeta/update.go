@@ -1,29 +1,21 @@
 package provisionerbeta
 
 import (
-	"bytes"
-	"crypto/ecdsa"
-	"crypto/ed25519"
-	"crypto/rsa"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
-	"net/url"
 	"os"
 
 	"github.com/pkg/errors"
 	"github.com/smallstep/certificates/ca"
-	"github.com/smallstep/cli/crypto/pemutil"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/jose"
 	"github.com/smallstep/cli/utils"
 	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
 	"go.step.sm/linkedca"
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 func updateCommand() cli.Command {
@@ -31,44 +23,44 @@ func updateCommand() cli.Command {
 		Name:   "update",
 		Action: cli.ActionFunc(updateAction),
 		Usage:  "update a provisioner",
-		UsageText: `**step beta ca provisioner update** <name> [**--public-key**=<file>]
+		UsageText: `**step beta ca provisioner update** <name> [**--public-key**=<file>|**--key**=<file>]
 [**--private-key**=<file>] [**--create**] [**--password-file**=<file>]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 ACME
 
 **step beta ca provisioner update** <name> [**--force-cn**] [**--require-eab**] [**--disable-eab**]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 OIDC
 
 **step beta ca provisioner update** <name>
 [**--client-id**=<id>] [**--client-secret**=<secret>]
-[**--configuration-endpoint**=<url>] [**--listen-address=<address>]
+[**--configuration-endpoint**=<url>] [**--configuration-snapshot**=<file>] [**--listen-address=<address>]
 [**--domain**=<domain>] [**--remove-domain**=<domain>]
 [**--group**=<group>] [**--remove-group**=<group>]
 [**--admin**=<email>]... [**--remove-admin**=<email>]...
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 X5C
 
 **step beta ca provisioner update** <name> **--x5c-root**=<file>
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 Kubernetes Service Account
 
-**step beta ca provisioner update** <name> [**--public-key**=<file>]
+**step beta ca provisioner update** <name> [**--pem-keys**=<file>]
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 IID (AWS/GCP/Azure)
 
@@ -76,12 +68,13 @@ IID (AWS/GCP/Azure)
 [**--aws-account**=<id>]... [**--remove-aws-account**=<id>]...
 [**--gcp-service-account**=<name>]... [**--remove-gcp-service-account**=<name>]...
 [**--gcp-project**=<name>]... [**--remove-gcp-project**=<name>]...
-[**--azure-tenant**=<id>] [**--azure-resource-group**=<name>] [**--azure-subscription-id**=<id>] [**--azure-object-id**=<id>]
+[**--azure-tenant**=<id>] [**--azure-audience**=<uri>] [**--azure-resource-group**=<name>] [**--azure-subscription-id**=<id>] [**--azure-object-id**=<id>]
 [**--instance-age**=<duration>] [**--iid-roots**=<file>]
 [**--disable-custom-sans**] [**--disable-trust-on-first-use**]
+[**--default-san**=<san>]... [**--remove-default-san**=<san>]...
 [**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
-[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--ca-url**=<uri>]
-[**--root**=<file>] [**--context**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]
 
 **step beta ca provisioner update** <name> [**--force-cn**] [**--challenge**=<challenge>] 
 [**--capabilities**=<capabilities>] [**--include-root**] [**--minimum-public-key-length**=<length>] 
@@ -96,11 +89,29 @@ IID (AWS/GCP/Azure)
 			},
 			x509TemplateFlag,
 			x509TemplateDataFlag,
+			x509TemplateDataJSONFlag,
 			sshTemplateFlag,
 			sshTemplateDataFlag,
+			sshTemplateDataJSONFlag,
+			cli.BoolFlag{
+				Name: "remove-x509-template",
+				Usage: `Remove the x509 certificate template and template data of the provisioner,
+the default template of the CA will be used.`,
+			},
+			cli.BoolFlag{
+				Name: "remove-ssh-template",
+				Usage: `Remove the ssh certificate template and template data of the provisioner,
+the default template of the CA will be used.`,
+			},
+			expandEnvFlag,
+			insecureTemplateFlag,
+			maxDurFlag,
 			x509MinDurFlag,
 			x509MaxDurFlag,
 			x509DefaultDurFlag,
+			minTLSCertDurationFlag,
+			maxTLSCertDurationFlag,
+			defaultTLSCertDurationFlag,
 			sshUserMinDurFlag,
 			sshUserMaxDurFlag,
 			sshUserDefaultDurFlag,
@@ -109,8 +120,10 @@ IID (AWS/GCP/Azure)
 			sshHostDefaultDurFlag,
 			disableRenewalFlag,
 			allowRenewalAfterExpiryFlag,
+			claimsJSONFlag,
 			enableX509Flag,
 			enableSSHFlag,
+			enableSSHCAFlag,
 
 			// JWK provisioner flags
 			cli.BoolFlag{
@@ -122,8 +135,11 @@ IID (AWS/GCP/Azure)
 				Usage: `The <file> containing the JWK private key.`,
 			},
 			cli.StringFlag{
-				Name:  "public-key",
-				Usage: `The <file> containing the JWK public key.`,
+				Name: "public-key, key",
+				Usage: `The <file> containing the JWK public key. Replacing the public key rotates the
+provisioner key, the tokens signed with the old key will no longer be valid. If
+**--private-key** is not set, the encrypted private key stored in the CA is
+removed.`,
 			},
 
 			// OIDC provisioner flags
@@ -140,9 +156,13 @@ IID (AWS/GCP/Azure)
 				Usage: `The callback <address> used in the OpenID Connect flow (e.g. \":10000\")`,
 			},
 			cli.StringFlag{
-				Name:  "configuration-endpoint",
-				Usage: `OpenID Connect configuration <url>.`,
+				Name: "configuration-endpoint",
+				Usage: `OpenID Connect configuration <url>. The discovery document is retrieved and
+validated before updating the provisioner. The system roots and the CA root in
+**--root** are trusted to connect to the <url>. Use **--insecure** to allow an
+http <url>.`,
 			},
+			oidcConfigurationSnapshotFlag,
 			cli.StringSliceFlag{
 				Name: "admin",
 				Usage: `The <email> of an admin user in an OpenID Connect provisioner, this user
@@ -154,11 +174,26 @@ will not have restrictions in the certificates to sign. Use the
 				Usage: `Remove the <email> of an admin user in an OpenID Connect provisioner, this user
 will not have restrictions in the certificates to sign. Use the
 '--admin' flag multiple times to configure multiple administrators.`,
+			},
+			cli.StringSliceFlag{
+				Name: "domain",
+				Usage: `The <domain> used to validate the email claim in an OpenID Connect provisioner.
+Use the '--domain' flag multiple times to configure multiple domains.`,
+			},
+			cli.StringSliceFlag{
+				Name: "remove-domain",
+				Usage: `Remove the <domain> used to validate the email claim in an OpenID Connect provisioner.
+Use the '--remove-domain' flag multiple times to remove multiple domains.`,
 			},
 			cli.StringSliceFlag{
 				Name: "group",
 				Usage: `The <group> list used to validate the groups extenstion in an OpenID Connect token.
 Use the '--group' flag multiple times to configure multiple groups.`,
+			},
+			cli.StringSliceFlag{
+				Name: "remove-group",
+				Usage: `Remove a <group> from the list used to validate the groups extension in an OpenID Connect token.
+Use the '--remove-group' flag multiple times to remove multiple groups.`,
 			},
 			cli.StringFlag{
 				Name:  "tenant-id",
@@ -166,11 +201,10 @@ Use the '--group' flag multiple times to configure multiple groups.`,
 			},
 
 			// X5C provisioner flags
-			cli.StringFlag{
-				Name: "x5c-root",
-				Usage: `Root certificate (chain) <file> used to validate the signature on X5C
-provisioning tokens.`,
-			},
+			x5cRootFlag,
+
+			// K8SSA provisioner flags
+			pemKeysFlag,
 
 			// Nebula provisioner flags
 			nebulaRootFlag,
@@ -191,6 +225,7 @@ provisioning tokens.`,
 			awsAccountFlag,
 			removeAWSAccountFlag,
 			azureTenantFlag,
+			azureAudienceFlag,
 			azureResourceGroupFlag,
 			removeAzureResourceGroupFlag,
 			azureSubscriptionIDFlag,
@@ -202,21 +237,36 @@ provisioning tokens.`,
 			gcpProjectFlag,
 			removeGCPProjectFlag,
 			instanceAgeFlag,
+			instanceAgeWarningFlag,
 			iidRootsFlag,
 			disableCustomSANsFlag,
 			disableTOFUFlag,
+			defaultSANFlag,
+			removeDefaultSANFlag,
 
+			dryRunFlag,
+			waitFlag,
+			waitIntervalFlag,
+			waitTimeoutFlag,
 			flags.AdminCert,
 			flags.AdminKey,
 			flags.AdminProvisioner,
 			flags.AdminSubject,
 			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
 			flags.CaURL,
 			flags.Root,
 			flags.Context,
 		},
 		Description: `**step ca provisioner update** updates a provisioner in the CA configuration.
 
+Before updating the provisioner, the fields that change are printed to STDERR
+with their old and new values. Use **--dry-run** to review the changes without
+modifying the CA configuration.
+
 ## POSITIONAL ARGUMENTS
 
 <name>
@@ -229,16 +279,38 @@ Update a JWK provisioner with newly generated keys and a template for x509 certi
 step beta ca provisioner update cicd --create --x509-template ./templates/example.tpl
 '''
 
+Remove the x509 template of a provisioner to use the default one:
+'''
+step beta ca provisioner update cicd --remove-x509-template
+'''
+
 Update a JWK provisioner with duration claims:
 '''
 step beta ca provisioner update cicd --create --x509-min-dur 20m --x509-default-dur 48h --ssh-user-min-dur 17m --ssh-host-default-dur 16h
 '''
 
+Update a JWK provisioner with the claims in a JSON file:
+'''
+step beta ca provisioner update cicd --claims-json claims.json
+'''
+
+Update a JWK provisioner to limit all certificates to 12h:
+'''
+step beta ca provisioner update cicd --max-dur 12h
+'''
+
 Update a JWK provisioner with existing keys:
 '''
 step beta ca provisioner update jane@doe.com --public-key jwk.pub --private-key jwk.priv
 '''
 
+Rotate the key of a JWK provisioner, storing the new private key encrypted with
+the password in a file:
+'''
+step crypto jwk create new.pub new.priv
+step beta ca provisioner update jane@doe.com --key new.pub --private-key new.priv --password-file pass.txt
+'''
+
 Update a JWK provisioner to disable ssh provisioning:
 '''
 step beta ca provisioner update cicd --ssh=false
@@ -262,7 +334,7 @@ step beta ca provisioner update acme --force-cn --require-eab
 
 Update an K8SSA provisioner:
 '''
-step beta ca provisioner update kube --public-key key.pub --x509-min-duration 30m
+step beta ca provisioner update kube --pem-keys key.pub --x509-min-duration 30m
 '''
 
 Update an Azure provisioner:
@@ -282,9 +354,25 @@ Update an AWS provisioner:
 $ step beta ca provisioner update Amazon --disable-custom-sans --disable-trust-on-first-use
 '''
 
+Replace all the projects of a GCP provisioner with a new one:
+'''
+$ step beta ca provisioner update Google --remove-gcp-project '*' --gcp-project internal
+'''
+
+Replace a default SAN of an AWS provisioner:
+'''
+$ step beta ca provisioner update Amazon \
+  --remove-default-san old.example.com --default-san new.example.com
+'''
+
 Update a SCEP provisioner:
 '''
 step beta ca provisioner update my_scep_provisioner --force-cn
+'''
+
+Print the result of updating a provisioner without modifying the CA:
+'''
+step beta ca provisioner update cicd --x509-max-dur 48h --dry-run
 '''`,
 	}
 }
@@ -294,6 +382,10 @@ func updateAction(ctx *cli.Context) (err error) {
 		return err
 	}
 
+	if err = applyDeprecatedDurFlags(ctx, os.Stderr); err != nil {
+		return err
+	}
+
 	args := ctx.Args()
 	name := args[0]
 
@@ -307,6 +399,7 @@ func updateAction(ctx *cli.Context) (err error) {
 	if err != nil {
 		return err
 	}
+	old := proto.Clone(p).(*linkedca.Provisioner)
 
 	if ctx.IsSet("name") {
 		p.Name = ctx.String("name")
@@ -314,7 +407,32 @@ func updateAction(ctx *cli.Context) (err error) {
 	if err := updateTemplates(ctx, p); err != nil {
 		return err
 	}
+	if err := updateDefaultSANs(ctx, p); err != nil {
+		return err
+	}
+	for _, w := range defaultSANsWarnings(ctx, old, p) {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s.\n", p.Name, w)
+	}
 	updateClaims(ctx, p)
+	if err := applyEnableSSHCA(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := applyClaimsJSON(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := applyMaxDur(ctx, p.Claims); err != nil {
+		return err
+	}
+	if err := validateClaims(ctx, p.Claims); err != nil {
+		return err
+	}
+
+	if err := validateEABFlags(ctx, p.Type); err != nil {
+		return err
+	}
+	if err := validateCloudFlags(ctx, p.Type); err != nil {
+		return err
+	}
 
 	switch p.Type {
 	case linkedca.Provisioner_JWK:
@@ -346,75 +464,92 @@ func updateAction(ctx *cli.Context) (err error) {
 		return err
 	}
 
-	if err := client.UpdateProvisioner(name, p); err != nil {
+	diffs, err := diffProvisioners(old, p)
+	if err != nil {
 		return err
 	}
+	printDiff(os.Stderr, name, diffs)
 
-	var buf bytes.Buffer
-	b, err := protojson.Marshal(p)
-	if err != nil {
+	if ctx.Bool("dry-run") {
+		if err := printBackend(ctx, os.Stderr); err != nil {
+			return err
+		}
+		return printProvisioner(p)
+	}
+
+	if err := client.UpdateProvisioner(name, p); err != nil {
 		return err
 	}
-	if err := json.Indent(&buf, b, "", "  "); err != nil {
+	if err := waitForChange(ctx, client, old, p); err != nil {
 		return err
 	}
-	fmt.Println(buf.String())
 
-	return nil
+	return printProvisioner(p)
 }
 
 func updateTemplates(ctx *cli.Context, p *linkedca.Provisioner) error {
+	for _, prefix := range []string{"x509", "ssh"} {
+		if !ctx.Bool("remove-" + prefix + "-template") {
+			continue
+		}
+		for _, name := range []string{"-template", "-template-data", "-template-data-json"} {
+			if ctx.IsSet(prefix + name) {
+				return errs.IncompatibleFlagWithFlag(ctx, "remove-"+prefix+"-template", prefix+name)
+			}
+		}
+	}
+
 	// Read x509 template if passed
 	if p.X509Template == nil {
 		p.X509Template = &linkedca.Template{}
 	}
+	if ctx.Bool("remove-x509-template") {
+		p.X509Template.Template = nil
+		p.X509Template.Data = nil
+	}
 	if x509TemplateFile := ctx.String("x509-template"); ctx.IsSet("x509-template") {
 		if x509TemplateFile == "" {
 			p.X509Template.Template = nil
 		} else {
-			b, err := utils.ReadFile(x509TemplateFile)
+			b, err := readTemplate(ctx, "x509-template")
 			if err != nil {
 				return err
 			}
 			p.X509Template.Template = b
 		}
 	}
-	if x509TemplateDataFile := ctx.String("x509-template-data"); ctx.IsSet("x509-template-data") {
-		if x509TemplateDataFile == "" {
-			p.X509Template.Data = nil
-		} else {
-			b, err := utils.ReadFile(x509TemplateDataFile)
-			if err != nil {
-				return err
-			}
-			p.X509Template.Data = b
+	if ctx.IsSet("x509-template-data") || ctx.IsSet("x509-template-data-json") {
+		b, err := readTemplateData(ctx, "x509-template-data")
+		if err != nil {
+			return err
 		}
+		p.X509Template.Data = b
 	}
 	// Read ssh template if passed
 	if p.SshTemplate == nil {
 		p.SshTemplate = &linkedca.Template{}
 	}
+	if ctx.Bool("remove-ssh-template") {
+		p.SshTemplate.Template = nil
+		p.SshTemplate.Data = nil
+	}
 	if sshTemplateFile := ctx.String("ssh-template"); ctx.IsSet("ssh-template") {
 		if sshTemplateFile == "" {
 			p.SshTemplate.Template = nil
 		} else {
-			b, err := utils.ReadFile(sshTemplateFile)
+			b, err := readTemplate(ctx, "ssh-template")
 			if err != nil {
 				return err
 			}
 			p.SshTemplate.Template = b
 		}
 	}
-	if sshTemplateDataFile := ctx.String("ssh-template-data"); ctx.IsSet("ssh-template-data") {
-		if sshTemplateDataFile == "" {
-			p.SshTemplate.Data = nil
-		} else {
-			b, err := utils.ReadFile(sshTemplateDataFile)
-			if err != nil {
-				return err
-			}
-			p.SshTemplate.Data = b
+	if ctx.IsSet("ssh-template-data") || ctx.IsSet("ssh-template-data-json") {
+		b, err := readTemplateData(ctx, "ssh-template-data")
+		if err != nil {
+			return err
 		}
+		p.SshTemplate.Data = b
 	}
 	return nil
 }
@@ -499,15 +634,17 @@ func updateJWKDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 		password string
 	)
 	if passwordFile := ctx.String("password-file"); len(passwordFile) > 0 {
-		password, err = utils.ReadStringPasswordFromFile(passwordFile)
-		if err != nil {
+		var b []byte
+		if b, err = utils.ReadPasswordFile(passwordFile); err != nil {
 			return err
 		}
+		password = string(b)
 	}
 
 	var (
-		jwk *jose.JSONWebKey
-		jwe *jose.JSONWebEncryption
+		jwk     *jose.JSONWebKey
+		jwe     *jose.JSONWebEncryption
+		privjwk *jose.JSONWebKey
 	)
 	if ctx.Bool("create") {
 		if ctx.IsSet("public-key") {
@@ -536,6 +673,8 @@ func updateJWKDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 			if _, ok := jwk.Key.([]byte); ok {
 				return errors.New("invalid JWK: a symmetric key cannot be used as a provisioner")
 			}
+			// Never store private key material as the public key
+			jwk = publicJWK(jwkFile, jwk)
 			// Create kid if not present
 			if jwk.KeyID == "" {
 				jwk.KeyID, err = jose.Thumbprint(jwk)
@@ -559,8 +698,22 @@ func updateJWKDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 			//
 			// Attempt to parse as decrypted private key.
 			jwe, err = jose.ParseEncrypted(string(b))
-			if err != nil {
-				privjwk, err := jose.ParseKey(jwkFile)
+			switch {
+			case err == nil:
+				// Decrypt the private key to check it against the public key.
+				var opts []jose.Option
+				if password != "" {
+					opts = append(opts, jose.WithPassword([]byte(password)))
+				}
+				if b, err = jose.Decrypt("Please enter the password to decrypt the provisioner private key", b, opts...); err != nil {
+					return err
+				}
+				privjwk = new(jose.JSONWebKey)
+				if err = json.Unmarshal(b, privjwk); err != nil {
+					return errors.Wrapf(err, "error parsing %s", jwkFile)
+				}
+			case err != nil:
+				privjwk, err = jose.ParseKey(jwkFile)
 				if err != nil {
 					return errs.FileError(err, jwkFile)
 				}
@@ -571,8 +724,8 @@ func updateJWKDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 
 				// Encrypt JWK
 				opts := []jose.Option{}
-				if ctx.IsSet("password-file") {
-					opts = append(opts, jose.WithPasswordFile(ctx.String("password-file")))
+				if password != "" {
+					opts = append(opts, jose.WithPassword([]byte(password)))
 				}
 				jwe, err = jose.EncryptJWK(privjwk, opts...)
 				if err != nil {
@@ -582,11 +735,33 @@ func updateJWKDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 		}
 	}
 
+	if privjwk != nil {
+		// Check the private key against the new public key, or against the
+		// current one if it does not change.
+		pub := jwk
+		if pub == nil {
+			pub = new(jose.JSONWebKey)
+			if err := json.Unmarshal(details.PublicKey, pub); err != nil {
+				return errors.Wrap(err, "error parsing the public key of the provisioner")
+			}
+		}
+		if err := validateJWKKeyPair(pub, privjwk); err != nil {
+			return err
+		}
+	}
+
 	if jwk != nil {
 		jwkPubBytes, err := jwk.MarshalJSON()
 		if err != nil {
 			return errors.Wrap(err, "error marshaling JWK")
 		}
+		if old, ok := rotatedJWK(details.PublicKey, jwk); ok {
+			warnJWKRotation(p.Name, old, jwk)
+			// The old private key does not match the new public key.
+			if jwe == nil {
+				details.EncryptedPrivateKey = nil
+			}
+		}
 		details.PublicKey = jwkPubBytes
 	}
 
@@ -601,6 +776,51 @@ func updateJWKDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 	return nil
 }
 
+// validateJWKKeyPair checks that the given private key corresponds to the
+// public key.
+func validateJWKKeyPair(pub, priv *jose.JSONWebKey) error {
+	pubThumbprint, err := jose.Thumbprint(pub)
+	if err != nil {
+		return err
+	}
+	privThumbprint, err := jose.Thumbprint(priv)
+	if err != nil {
+		return err
+	}
+	if pubThumbprint != privThumbprint {
+		return errors.New("invalid jwk: private-key does not match the public-key")
+	}
+	return nil
+}
+
+// rotatedJWK returns the current public key of a provisioner and true if it is
+// not the same key as the given one.
+func rotatedJWK(oldKey []byte, jwk *jose.JSONWebKey) (*jose.JSONWebKey, bool) {
+	old := new(jose.JSONWebKey)
+	if err := json.Unmarshal(oldKey, old); err != nil {
+		return old, true
+	}
+	oldThumbprint, err := jose.Thumbprint(old)
+	if err != nil {
+		return old, true
+	}
+	newThumbprint, err := jose.Thumbprint(jwk)
+	if err != nil {
+		return old, true
+	}
+	return old, oldThumbprint != newThumbprint
+}
+
+// warnJWKRotation prints a warning about the consequences of replacing the key
+// of a JWK provisioner.
+func warnJWKRotation(name string, old, jwk *jose.JSONWebKey) {
+	fmt.Fprintf(os.Stderr, `WARNING: the key of provisioner %s will be replaced.
+The tokens signed with the old key (kid %s) will no longer be valid, and
+the clients using it must be updated to use the new key (kid %s).
+
+`, name, old.KeyID, jwk.KeyID)
+}
+
 func updateACMEDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 	data, ok := p.Details.GetData().(*linkedca.ProvisionerDetails_ACME)
 	if !ok {
@@ -610,15 +830,10 @@ func updateACMEDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 	if ctx.IsSet("force-cn") {
 		details.ForceCn = ctx.Bool("force-cn")
 	}
-	requireEABSet := ctx.IsSet("require-eab")
-	disableEABSet := ctx.IsSet("disable-eab")
-	if requireEABSet && disableEABSet {
-		return errs.IncompatibleFlagWithFlag(ctx, "require-eab", "disable-eab")
-	}
-	if requireEABSet {
+	if ctx.IsSet("require-eab") {
 		details.RequireEab = ctx.Bool("require-eab")
 	}
-	if disableEABSet {
+	if ctx.IsSet("disable-eab") {
 		details.RequireEab = false
 	}
 	return nil
@@ -635,23 +850,9 @@ func updateX5CDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 	}
 	details := data.X5C
 	if ctx.IsSet("x5c-root") {
-		x5cRootFile := ctx.String("x5c-root")
-		roots, err := pemutil.ReadCertificateBundle(x5cRootFile)
+		rootBytes, err := readX5CRoots(ctx.StringSlice("x5c-root"))
 		if err != nil {
-			return errors.Wrapf(err, "error loading X5C Root certificates from %s", x5cRootFile)
-		}
-		var rootBytes [][]byte
-		for _, r := range roots {
-			if r.KeyUsage&x509.KeyUsageCertSign == 0 {
-				return errors.Errorf("error: certificate with common name '%s' cannot be "+
-					"used as an X5C root certificate.\n\n"+
-					"X5C provisioner root certificates must have the 'Certificate Sign' key "+
-					"usage extension.", r.Subject.CommonName)
-			}
-			rootBytes = append(rootBytes, pem.EncodeToMemory(&pem.Block{
-				Type:  "CERTIFICATE",
-				Bytes: r.Raw,
-			}))
+			return err
 		}
 		details.Roots = rootBytes
 	}
@@ -666,7 +867,7 @@ func updateNebulaDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 
 	details := data.Nebula
 	if ctx.IsSet("nebula-root") {
-		rootBytes, err := readNebulaRoots(ctx.String("nebula-root"))
+		rootBytes, err := readNebulaRoots(ctx.StringSlice("nebula-root"))
 		if err != nil {
 			return err
 		}
@@ -682,42 +883,14 @@ func updateK8SSADetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 		return errors.New("error casting details to K8SSA type")
 	}
 	details := data.K8SSA
-	if ctx.IsSet("public-key") {
-		pemKeysF := ctx.String("public-key")
-		pemKeysB, err := os.ReadFile(pemKeysF)
+	keyFiles, err := k8sSAKeyFiles(ctx)
+	if err != nil {
+		return err
+	}
+	if len(keyFiles) > 0 {
+		pubKeyBytes, err := readK8SSAPublicKeys(keyFiles)
 		if err != nil {
-			return errors.Wrap(err, "error reading pem keys")
-		}
-
-		var (
-			block   *pem.Block
-			rest    = pemKeysB
-			pemKeys = []interface{}{}
-		)
-		for rest != nil {
-			block, rest = pem.Decode(rest)
-			if block == nil {
-				break
-			}
-			key, err := pemutil.ParseKey(pem.EncodeToMemory(block))
-			if err != nil {
-				return errors.Wrapf(err, "error parsing public key from %s", pemKeysF)
-			}
-			switch q := key.(type) {
-			case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
-			default:
-				return errors.Errorf("Unexpected public key type %T in %s", q, pemKeysF)
-			}
-			pemKeys = append(pemKeys, key)
-		}
-
-		var pubKeyBytes [][]byte
-		for _, k := range pemKeys {
-			blk, err := pemutil.Serialize(k)
-			if err != nil {
-				return errors.Wrap(err, "error serializing pem key")
-			}
-			pubKeyBytes = append(pubKeyBytes, pem.EncodeToMemory(blk))
+			return err
 		}
 		details.PublicKeys = pubKeyBytes
 	}
@@ -762,9 +935,11 @@ func updateOIDCDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 	}
 	if ctx.IsSet("configuration-endpoint") {
 		ce := ctx.String("configuration-endpoint")
-		u, err := url.Parse(ce)
-		if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
-			return errs.InvalidFlagValue(ctx, "configuration-endpoint", ce, "")
+		if err := checkConfigurationEndpoint(ctx, ce); err != nil {
+			return err
+		}
+		if err := validateOIDCConfiguration(ctx, ce, details.TenantId); err != nil {
+			return errs.InvalidFlagValueMsg(ctx, "configuration-endpoint", ce, err.Error())
 		}
 		details.ConfigurationEndpoint = ce
 	}
@@ -789,13 +964,13 @@ func updateAWSDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 		details.DisableCustomSans = ctx.Bool("disable-custom-sans")
 	}
 	if ctx.IsSet("disable-trust-on-first-use") {
-		details.DisableCustomSans = ctx.Bool("disable-trust-on-first-use")
+		details.DisableTrustOnFirstUse = ctx.Bool("disable-trust-on-first-use")
 	}
 	if ctx.IsSet("remove-aws-account") {
-		details.Accounts = removeElements(details.Accounts, ctx.StringSlice("remove-aws-account"))
+		details.Accounts = removeCloudElements(details.Accounts, ctx.StringSlice("remove-aws-account"))
 	}
 	if ctx.IsSet("aws-account") {
-		details.Accounts = append(details.Accounts, ctx.StringSlice("aws-account")...)
+		details.Accounts = appendUniqueElements(details.Accounts, ctx.StringSlice("aws-account"))
 	}
 	return nil
 }
@@ -810,26 +985,33 @@ func updateAzureDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 	if ctx.IsSet("azure-tenant") {
 		details.TenantId = ctx.String("azure-tenant")
 	}
+	if ctx.IsSet("azure-audience") {
+		audience, err := parseAzureAudience(ctx)
+		if err != nil {
+			return err
+		}
+		details.Audience = audience
+	}
 	if ctx.IsSet("disable-custom-sans") {
 		details.DisableCustomSans = ctx.Bool("disable-custom-sans")
 	}
 	if ctx.IsSet("disable-trust-on-first-use") {
-		details.DisableCustomSans = ctx.Bool("disable-trust-on-first-use")
+		details.DisableTrustOnFirstUse = ctx.Bool("disable-trust-on-first-use")
 	}
 	if ctx.IsSet("remove-azure-resource-group") {
-		details.ResourceGroups = removeElements(details.ResourceGroups, ctx.StringSlice("remove-azure-resource-group"))
+		details.ResourceGroups = removeCloudElements(details.ResourceGroups, ctx.StringSlice("remove-azure-resource-group"))
 	}
 	if ctx.IsSet("azure-resource-group") {
 		details.ResourceGroups = append(details.ResourceGroups, ctx.StringSlice("azure-resource-group")...)
 	}
 	if ctx.IsSet("remove-azure-subscription-id") {
-		details.SubscriptionIds = removeElements(details.SubscriptionIds, ctx.StringSlice("remove-azure-subscription-id"))
+		details.SubscriptionIds = removeCloudElements(details.SubscriptionIds, ctx.StringSlice("remove-azure-subscription-id"))
 	}
 	if ctx.IsSet("azure-subscription-id") {
 		details.SubscriptionIds = append(details.SubscriptionIds, ctx.StringSlice("azure-subscription-id")...)
 	}
 	if ctx.IsSet("remove-azure-object-id") {
-		details.ObjectIds = removeElements(details.ObjectIds, ctx.StringSlice("remove-azure-object-id"))
+		details.ObjectIds = removeCloudElements(details.ObjectIds, ctx.StringSlice("remove-azure-object-id"))
 	}
 	if ctx.IsSet("azure-object-id") {
 		details.ObjectIds = append(details.ObjectIds, ctx.StringSlice("azure-object-id")...)
@@ -855,16 +1037,16 @@ func updateGCPDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 		details.DisableCustomSans = ctx.Bool("disable-custom-sans")
 	}
 	if ctx.IsSet("disable-trust-on-first-use") {
-		details.DisableCustomSans = ctx.Bool("disable-trust-on-first-use")
+		details.DisableTrustOnFirstUse = ctx.Bool("disable-trust-on-first-use")
 	}
 	if ctx.IsSet("remove-gcp-service-account") {
-		details.ServiceAccounts = removeElements(details.ServiceAccounts, ctx.StringSlice("remove-gcp-service-account"))
+		details.ServiceAccounts = removeCloudElements(details.ServiceAccounts, ctx.StringSlice("remove-gcp-service-account"))
 	}
 	if ctx.IsSet("gcp-service-account") {
 		details.ServiceAccounts = append(details.ServiceAccounts, ctx.StringSlice("gcp-service-account")...)
 	}
 	if ctx.IsSet("remove-gcp-project") {
-		details.ProjectIds = removeElements(details.ProjectIds, ctx.StringSlice("remove-gcp-project"))
+		details.ProjectIds = removeCloudElements(details.ProjectIds, ctx.StringSlice("remove-gcp-project"))
 	}
 	if ctx.IsSet("gcp-project") {
 		details.ProjectIds = append(details.ProjectIds, ctx.StringSlice("gcp-project")...)
@@ -889,13 +1071,21 @@ func updateSCEPDetails(ctx *cli.Context, p *linkedca.Provisioner) error {
 		details.Capabilities = ctx.StringSlice("capabilities")
 	}
 	if ctx.IsSet("min-public-key-length") {
-		details.MinimumPublicKeyLength = int32(ctx.Int("min-public-key-length"))
+		length, err := parseSCEPMinimumPublicKeyLength(ctx)
+		if err != nil {
+			return err
+		}
+		details.MinimumPublicKeyLength = length
 	}
 	if ctx.IsSet("include-root") {
 		details.IncludeRoot = ctx.Bool("include-root")
 	}
 	if ctx.IsSet("encryption-algorithm-identifier") {
-		details.EncryptionAlgorithmIdentifier = int32(ctx.Int("encryption-algorithm-identifier"))
+		alg, err := parseSCEPEncryptionAlgorithm(ctx)
+		if err != nil {
+			return err
+		}
+		details.EncryptionAlgorithmIdentifier = alg
 	}
 
 	return nil
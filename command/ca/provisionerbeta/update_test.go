@@ -0,0 +1,500 @@
+package provisionerbeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/smallstep/cli/jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+)
+
+func newTestContext(t *testing.T, flags []cli.Flag, args []string) *cli.Context {
+	t.Helper()
+	app := &cli.App{}
+	set := flag.NewFlagSet("contrive", 0)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	// Copy the values of the flags set using an alias, as cli.Command does.
+	visited := make(map[string]*flag.Flag)
+	set.Visit(func(f *flag.Flag) {
+		visited[f.Name] = f
+	})
+	for _, f := range flags {
+		names := strings.Split(f.GetName(), ",")
+		for _, name := range names {
+			if ff, ok := visited[strings.TrimSpace(name)]; ok {
+				for _, alias := range names {
+					alias = strings.TrimSpace(alias)
+					if _, ok := visited[alias]; ok {
+						continue
+					}
+					if err := set.Set(alias, ff.Value.String()); err != nil {
+						t.Fatal(err)
+					}
+				}
+				break
+			}
+		}
+	}
+	return cli.NewContext(app, set, nil)
+}
+
+func TestUpdateClaims_enableSSHCA(t *testing.T) {
+	flags := []cli.Flag{enableSSHFlag, enableSSHCAFlag, sshUserMaxDurFlag}
+	tests := []struct {
+		name    string
+		enabled bool
+		args    []string
+		want    bool
+		wantErr bool
+	}{
+		{"set true", false, []string{"--enable-ssh-ca=true"}, true, false},
+		{"set false", true, []string{"--enable-ssh-ca=false"}, false, false},
+		{"unset preserves true", true, []string{"--ssh-user-max-dur", "1h"}, true, false},
+		{"unset preserves false", false, []string{"--ssh-user-max-dur", "1h"}, false, false},
+		{"invalid", false, []string{"--enable-ssh-ca=maybe"}, false, true},
+		{"with ssh", false, []string{"--ssh", "--enable-ssh-ca=true"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &linkedca.Provisioner{
+				Claims: &linkedca.Claims{
+					Ssh: &linkedca.SSHClaims{
+						Enabled:       tt.enabled,
+						UserDurations: &linkedca.Durations{Max: "8h"},
+					},
+				},
+			}
+			ctx := newTestContext(t, flags, tt.args)
+			updateClaims(ctx, p)
+			err := applyEnableSSHCA(ctx, p.Claims)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, p.Claims.Ssh.Enabled)
+		})
+	}
+}
+
+func TestUpdateAWSDetails_accounts(t *testing.T) {
+	flags := []cli.Flag{awsAccountFlag, removeAWSAccountFlag}
+	tests := []struct {
+		name     string
+		accounts []string
+		args     []string
+		want     []string
+	}{
+		{"add", []string{"1"}, []string{"--aws-account", "2"}, []string{"1", "2"}},
+		{"add existing", []string{"1", "2"}, []string{"--aws-account", "2", "--aws-account", "3"}, []string{"1", "2", "3"}},
+		{"remove", []string{"1", "2"}, []string{"--remove-aws-account", "1"}, []string{"2"}},
+		{"remove absent", []string{"1"}, []string{"--remove-aws-account", "2"}, []string{"1"}},
+		{"add and remove same", []string{"1", "2"}, []string{"--aws-account", "2", "--remove-aws-account", "2"}, []string{"1", "2"}},
+		{"add and remove", []string{"1", "2"}, []string{"--aws-account", "3", "--remove-aws-account", "1"}, []string{"2", "3"}},
+		{"dedupe", []string{"1", "1"}, []string{"--aws-account", "2", "--aws-account", "2"}, []string{"1", "2"}},
+		{"remove all", []string{"1", "2"}, []string{"--remove-aws-account", "*"}, []string{}},
+		{"remove all and add", []string{"1", "2"}, []string{"--remove-aws-account", "*", "--aws-account", "3"}, []string{"3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &linkedca.Provisioner{
+				Type: linkedca.Provisioner_AWS,
+				Details: &linkedca.ProvisionerDetails{
+					Data: &linkedca.ProvisionerDetails_AWS{
+						AWS: &linkedca.AWSProvisioner{Accounts: tt.accounts},
+					},
+				},
+			}
+			ctx := newTestContext(t, flags, tt.args)
+			assert.NoError(t, updateAWSDetails(ctx, p))
+			assert.Equal(t, tt.want, p.Details.GetAWS().Accounts)
+		})
+	}
+}
+
+func TestUpdateAWSDetails_cloudOptions(t *testing.T) {
+	p := &linkedca.Provisioner{
+		Type: linkedca.Provisioner_AWS,
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_AWS{AWS: &linkedca.AWSProvisioner{}},
+		},
+	}
+	ctx := newTestContext(t, []cli.Flag{disableCustomSANsFlag, disableTOFUFlag}, []string{"--disable-trust-on-first-use"})
+	require.NoError(t, updateAWSDetails(ctx, p))
+	assert.True(t, p.Details.GetAWS().DisableTrustOnFirstUse)
+	assert.False(t, p.Details.GetAWS().DisableCustomSans)
+}
+
+func TestUpdateDefaultSANs(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     *linkedca.Template
+		args         []string
+		wantData     string
+		wantTemplate string
+		wantErr      bool
+	}{
+		{"not set", nil, nil, "", "", false},
+		{"add", nil, []string{"--default-san", "example.com", "--default-san", "10.0.0.1", "--default-san", "jane@example.com", "--default-san", "spiffe://example.com/foo"},
+			`{"defaultSANs":[{"type":"dns","value":"example.com"},{"type":"ip","value":"10.0.0.1"},{"type":"email","value":"jane@example.com"},{"type":"uri","value":"spiffe://example.com/foo"}]}`,
+			defaultSANsTemplate, false},
+		{"keep template and data", &linkedca.Template{Template: []byte(`{"sans": {{ toJson .defaultSANs }}}`), Data: []byte(`{"foo":"bar"}`)}, []string{"--default-san", "example.com"},
+			`{"defaultSANs":[{"type":"dns","value":"example.com"}],"foo":"bar"}`, `{"sans": {{ toJson .defaultSANs }}}`, false},
+		{"template without default SANs", &linkedca.Template{Template: []byte("{}")}, []string{"--default-san", "example.com"}, "", "", true},
+		{"remove", &linkedca.Template{Template: []byte(defaultSANsTemplate), Data: []byte(`{"defaultSANs":[{"type":"dns","value":"a.example.com"},{"type":"dns","value":"b.example.com"}]}`)},
+			[]string{"--remove-default-san", "a.example.com"},
+			`{"defaultSANs":[{"type":"dns","value":"b.example.com"}]}`, defaultSANsTemplate, false},
+		{"remove all", &linkedca.Template{Template: []byte(defaultSANsTemplate), Data: []byte(`{"defaultSANs":[{"type":"dns","value":"a.example.com"}]}`)},
+			[]string{"--remove-default-san", "a.example.com"}, "", "", false},
+		{"invalid", nil, []string{"--default-san", "not a san"}, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &linkedca.Provisioner{X509Template: tt.template}
+			ctx := newTestContext(t, updateCommand().Flags, tt.args)
+			err := updateDefaultSANs(ctx, p)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantData, string(p.X509Template.GetData()))
+			assert.Equal(t, tt.wantTemplate, string(p.X509Template.GetTemplate()))
+		})
+	}
+}
+
+func TestDefaultSANsWarnings(t *testing.T) {
+	data := []byte(`{"defaultSANs":[{"type":"dns","value":"a.example.com"},{"type":"dns","value":"b.example.com"}]}`)
+	tests := []struct {
+		name     string
+		old      *linkedca.Template
+		template *linkedca.Template
+		args     []string
+		want     int
+	}{
+		{"no default SANs", nil, &linkedca.Template{Template: []byte("{}")}, nil, 0},
+		{"default template", nil, &linkedca.Template{Template: []byte(defaultSANsTemplate), Data: data}, nil, 0},
+		{"custom template", nil, &linkedca.Template{Template: []byte("{}"), Data: data}, nil, 1},
+		{"no template", nil, &linkedca.Template{Data: data}, nil, 1},
+		{"data replaced", &linkedca.Template{Template: []byte(defaultSANsTemplate), Data: data},
+			&linkedca.Template{Template: []byte(defaultSANsTemplate), Data: []byte(`{"foo":"bar"}`)}, nil, 1},
+		{"removed with flag", &linkedca.Template{Template: []byte(defaultSANsTemplate), Data: data},
+			&linkedca.Template{Template: []byte(defaultSANsTemplate), Data: []byte(`{"defaultSANs":[{"type":"dns","value":"b.example.com"}]}`)},
+			[]string{"--remove-default-san", "a.example.com"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, updateCommand().Flags, tt.args)
+			old := &linkedca.Provisioner{X509Template: tt.old}
+			p := &linkedca.Provisioner{X509Template: tt.template}
+			assert.Len(t, defaultSANsWarnings(ctx, old, p), tt.want)
+		})
+	}
+}
+
+func TestUpdateTemplates_remove(t *testing.T) {
+	newProvisioner := func() *linkedca.Provisioner {
+		return &linkedca.Provisioner{
+			X509Template: &linkedca.Template{Template: []byte(`{"subject": {{ toJson .Subject }}}`), Data: []byte(`{"foo":"bar"}`)},
+			SshTemplate:  &linkedca.Template{Template: []byte(`{"type": {{ toJson .Type }}}`), Data: []byte(`{"foo":"bar"}`)},
+		}
+	}
+
+	t.Run("x509", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--remove-x509-template"})
+		require.NoError(t, updateTemplates(ctx, p))
+		assert.Nil(t, p.X509Template.Template)
+		assert.Nil(t, p.X509Template.Data)
+		assert.Equal(t, newProvisioner().SshTemplate.Template, p.SshTemplate.Template)
+		assert.Equal(t, newProvisioner().SshTemplate.Data, p.SshTemplate.Data)
+	})
+
+	t.Run("ssh", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--remove-ssh-template"})
+		require.NoError(t, updateTemplates(ctx, p))
+		assert.Equal(t, newProvisioner().X509Template.Template, p.X509Template.Template)
+		assert.Equal(t, newProvisioner().X509Template.Data, p.X509Template.Data)
+		assert.Nil(t, p.SshTemplate.Template)
+		assert.Nil(t, p.SshTemplate.Data)
+	})
+
+	t.Run("incompatible", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--remove-x509-template", "--x509-template-data-json", `{"foo":"baz"}`})
+		assert.Error(t, updateTemplates(ctx, p))
+	})
+}
+
+func TestUpdateClaims_durations(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantX509 *linkedca.Durations
+		wantUser *linkedca.Durations
+		wantHost *linkedca.Durations
+	}{
+		{"x509", []string{"--x509-min-dur", "1m", "--x509-max-dur", "2h", "--x509-default-dur", "1h"},
+			&linkedca.Durations{Min: "1m", Max: "2h", Default: "1h"}, &linkedca.Durations{}, &linkedca.Durations{}},
+		{"ssh user", []string{"--ssh-user-min-dur", "1m", "--ssh-user-max-dur", "2h", "--ssh-user-default-dur", "1h"},
+			&linkedca.Durations{}, &linkedca.Durations{Min: "1m", Max: "2h", Default: "1h"}, &linkedca.Durations{}},
+		{"ssh host", []string{"--ssh-host-min-dur", "1m", "--ssh-host-max-dur", "24h", "--ssh-host-default-dur", "8h"},
+			&linkedca.Durations{}, &linkedca.Durations{}, &linkedca.Durations{Min: "1m", Max: "24h", Default: "8h"}},
+		{"ssh host default", []string{"--ssh-host-default-dur", "8h"},
+			&linkedca.Durations{}, &linkedca.Durations{}, &linkedca.Durations{Default: "8h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &linkedca.Provisioner{}
+			ctx := newTestContext(t, updateCommand().Flags, tt.args)
+			updateClaims(ctx, p)
+			assert.Equal(t, tt.wantX509, p.Claims.X509.Durations)
+			assert.Equal(t, tt.wantUser, p.Claims.Ssh.UserDurations)
+			assert.Equal(t, tt.wantHost, p.Claims.Ssh.HostDurations)
+		})
+	}
+}
+
+func TestDurationFlagsUsage(t *testing.T) {
+	for _, f := range []cli.StringFlag{
+		x509MinDurFlag, x509MaxDurFlag, x509DefaultDurFlag,
+		sshUserMinDurFlag, sshUserMaxDurFlag, sshUserDefaultDurFlag,
+		sshHostMinDurFlag, sshHostMaxDurFlag, sshHostDefaultDurFlag,
+	} {
+		name := strings.TrimSuffix(f.Name, "-dur")
+		kind := name[strings.LastIndexByte(name, '-')+1:]
+		want := map[string]string{"min": "The minimum", "max": "The maximum", "default": "The default"}[kind]
+		assert.True(t, strings.HasPrefix(f.Usage, want), "usage of --%s should start with %q", f.Name, want)
+	}
+}
+
+func TestUpdateJWKDetails_rotate(t *testing.T) {
+	dir := t.TempDir()
+	writeJWK := func(name string, v interface{}) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, b, 0600))
+		return filename
+	}
+	newJWK := func() *jose.JSONWebKey {
+		jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+		require.NoError(t, err)
+		jwk.KeyID, err = jose.Thumbprint(jwk)
+		require.NoError(t, err)
+		return jwk
+	}
+
+	oldJWK, newKey, otherKey := newJWK(), newJWK(), newJWK()
+	oldPub, err := json.Marshal(oldJWK.Public())
+	require.NoError(t, err)
+	oldPubFile := writeJWK("old.pub", oldJWK.Public())
+	newPubFile := writeJWK("new.pub", newKey.Public())
+	newPrivFile := writeJWK("new.priv", newKey)
+	otherPrivFile := writeJWK("other.priv", otherKey)
+	passwordFile := filepath.Join(dir, "password.txt")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("password\n"), 0600))
+
+	newProvisioner := func() *linkedca.Provisioner {
+		return &linkedca.Provisioner{
+			Name: "jane@doe.com",
+			Type: linkedca.Provisioner_JWK,
+			Details: &linkedca.ProvisionerDetails{
+				Data: &linkedca.ProvisionerDetails_JWK{
+					JWK: &linkedca.JWKProvisioner{
+						PublicKey:           oldPub,
+						EncryptedPrivateKey: []byte("old-encrypted-key"),
+					},
+				},
+			},
+		}
+	}
+	publicKey := func(t *testing.T, p *linkedca.Provisioner) *jose.JSONWebKey {
+		var jwk jose.JSONWebKey
+		require.NoError(t, json.Unmarshal(p.Details.GetJWK().PublicKey, &jwk))
+		return &jwk
+	}
+
+	t.Run("rotate public key", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--public-key", newPubFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, newKey.KeyID, publicKey(t, p).KeyID)
+		assert.Empty(t, p.Details.GetJWK().EncryptedPrivateKey)
+		assert.Equal(t, "jane@doe.com", p.Name)
+	})
+
+	t.Run("same public key", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--public-key", oldPubFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, oldJWK.KeyID, publicKey(t, p).KeyID)
+		assert.Equal(t, []byte("old-encrypted-key"), p.Details.GetJWK().EncryptedPrivateKey)
+	})
+
+	t.Run("rotate key pair", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--public-key", newPubFile, "--private-key", newPrivFile, "--password-file", passwordFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, newKey.KeyID, publicKey(t, p).KeyID)
+		jwe, err := jose.ParseEncrypted(string(p.Details.GetJWK().EncryptedPrivateKey))
+		require.NoError(t, err)
+		b, err := jwe.Decrypt([]byte("password"))
+		require.NoError(t, err)
+		var priv jose.JSONWebKey
+		require.NoError(t, json.Unmarshal(b, &priv))
+		assert.False(t, priv.IsPublic())
+	})
+
+	t.Run("mismatched private key", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--public-key", newPubFile, "--private-key", otherPrivFile, "--password-file", passwordFile})
+		assert.Error(t, updateJWKDetails(ctx, p))
+	})
+
+	t.Run("key alias", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--key", newPubFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, newKey.KeyID, publicKey(t, p).KeyID)
+		assert.Empty(t, p.Details.GetJWK().EncryptedPrivateKey)
+	})
+
+	t.Run("private key as public key", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--key", newPrivFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		jwk := publicKey(t, p)
+		assert.Equal(t, newKey.KeyID, jwk.KeyID)
+		assert.True(t, jwk.IsPublic())
+	})
+
+	encrypt := func(name string, jwk *jose.JSONWebKey) string {
+		jwe, err := jose.EncryptJWK(jwk, jose.WithPassword([]byte("password")))
+		require.NoError(t, err)
+		s, err := jwe.CompactSerialize()
+		require.NoError(t, err)
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(s), 0600))
+		return filename
+	}
+	newEncFile := encrypt("new.enc", newKey)
+	otherEncFile := encrypt("other.enc", otherKey)
+
+	t.Run("encrypted private key", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--public-key", newPubFile, "--private-key", newEncFile, "--password-file", passwordFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, newKey.KeyID, publicKey(t, p).KeyID)
+		b, err := os.ReadFile(newEncFile)
+		require.NoError(t, err)
+		assert.Equal(t, b, p.Details.GetJWK().EncryptedPrivateKey)
+	})
+
+	t.Run("mismatched private key only", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--private-key", otherPrivFile, "--password-file", passwordFile})
+		assert.Error(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, []byte("old-encrypted-key"), p.Details.GetJWK().EncryptedPrivateKey)
+	})
+
+	t.Run("mismatched encrypted private key only", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--private-key", otherEncFile, "--password-file", passwordFile})
+		assert.Error(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, []byte("old-encrypted-key"), p.Details.GetJWK().EncryptedPrivateKey)
+	})
+
+	t.Run("private key only", func(t *testing.T) {
+		p := newProvisioner()
+		oldEncFile := encrypt("old.enc", oldJWK)
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--private-key", oldEncFile, "--password-file", passwordFile})
+		require.NoError(t, updateJWKDetails(ctx, p))
+		assert.Equal(t, oldJWK.KeyID, publicKey(t, p).KeyID)
+		b, err := os.ReadFile(oldEncFile)
+		require.NoError(t, err)
+		assert.Equal(t, b, p.Details.GetJWK().EncryptedPrivateKey)
+	})
+
+	t.Run("mismatched encrypted private key", func(t *testing.T) {
+		p := newProvisioner()
+		ctx := newTestContext(t, updateCommand().Flags, []string{"--public-key", newPubFile, "--private-key", otherEncFile, "--password-file", passwordFile})
+		assert.Error(t, updateJWKDetails(ctx, p))
+	})
+}
+
+func TestApplyMaxDur(t *testing.T) {
+	tests := []struct {
+		name     string
+		ssh      bool
+		args     []string
+		wantX509 string
+		wantUser string
+		wantHost string
+		wantErr  bool
+	}{
+		{"all", true, []string{"--max-dur", "12h"}, "12h", "12h", "12h", false},
+		{"specific flag wins", true, []string{"--max-dur", "12h", "--ssh-host-max-dur", "720h"}, "12h", "12h", "720h", false},
+		{"ssh disabled", false, []string{"--max-dur", "12h"}, "12h", "", "", false},
+		{"not set", true, []string{"--x509-max-dur", "1h"}, "1h", "", "", false},
+		{"fail invalid", true, []string{"--max-dur", "12"}, "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &linkedca.Provisioner{
+				Claims: &linkedca.Claims{
+					X509: &linkedca.X509Claims{Enabled: true},
+					Ssh:  &linkedca.SSHClaims{Enabled: tt.ssh},
+				},
+			}
+			ctx := newTestContext(t, updateCommand().Flags, tt.args)
+			updateClaims(ctx, p)
+			err := applyMaxDur(ctx, p.Claims)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantX509, p.Claims.X509.Durations.Max)
+			assert.Equal(t, tt.wantUser, p.Claims.Ssh.UserDurations.Max)
+			assert.Equal(t, tt.wantHost, p.Claims.Ssh.HostDurations.Max)
+		})
+	}
+}
+
+func TestApplyDeprecatedDurFlags(t *testing.T) {
+	flags := updateCommand().Flags
+
+	ctx := newTestContext(t, flags, []string{"--default-tls-cert-duration", "2h", "--max-tls-cert-duration", "24h"})
+	var buf bytes.Buffer
+	require.NoError(t, applyDeprecatedDurFlags(ctx, &buf))
+	assert.Equal(t, "2h", ctx.String("x509-default-dur"))
+	assert.Equal(t, "24h", ctx.String("x509-max-dur"))
+	assert.False(t, ctx.IsSet("x509-min-dur"))
+	assert.Contains(t, buf.String(), "use --x509-default-dur instead")
+	assert.Contains(t, buf.String(), "use --x509-max-dur instead")
+
+	ctx = newTestContext(t, flags, []string{"--min-tls-cert-duration", "5m", "--x509-min-dur", "10m"})
+	assert.Error(t, applyDeprecatedDurFlags(ctx, &buf))
+
+	ctx = newTestContext(t, flags, []string{"--x509-min-dur", "10m"})
+	buf.Reset()
+	require.NoError(t, applyDeprecatedDurFlags(ctx, &buf))
+	assert.Equal(t, "10m", ctx.String("x509-min-dur"))
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,129 @@
+package provisionerbeta
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	nebula "github.com/slackhq/nebula/cert"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+)
+
+func validateRootsCommand() cli.Command {
+	return cli.Command{
+		Name:   "validate-roots",
+		Action: cli.ActionFunc(validateRootsAction),
+		Usage:  "validate the root certificates used by X5C and Nebula provisioners",
+		UsageText: `**step beta ca provisioner validate-roots**
+[**--x5c-root**=<file>] [**--nebula-root**=<file>]`,
+		Flags: []cli.Flag{
+			x5cRootFlag,
+			nebulaRootFlag,
+		},
+		Description: `**step beta ca provisioner validate-roots** reads the root certificates
+in the given files using the same validations done when an X5C or Nebula
+provisioner is created or updated, and prints the subject and expiration of
+each CA certificate found. Nothing is sent to the CA.
+
+## EXIT CODES
+
+This command returns '0' if all the files are valid, '2' if they are valid but
+any of the certificates is expired or not yet valid, and '1' otherwise.
+
+## EXAMPLES
+
+Validate the roots of an X5C provisioner:
+'''
+$ step beta ca provisioner validate-roots --x5c-root x5c_ca.crt
+'''
+
+Validate the roots of a Nebula provisioner in multiple files:
+'''
+$ step beta ca provisioner validate-roots --nebula-root ca1.crt --nebula-root ca2.crt
+'''
+`,
+	}
+}
+
+// invalidRootExitCode is the exit code used when any of the root certificates
+// is expired or not yet valid.
+const invalidRootExitCode = 2
+
+func validateRootsAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 0); err != nil {
+		return err
+	}
+
+	x5cRoots, nebulaRoots := ctx.StringSlice("x5c-root"), ctx.StringSlice("nebula-root")
+	if len(x5cRoots) == 0 && len(nebulaRoots) == 0 {
+		return errs.RequiredOrFlag(ctx, "x5c-root", "nebula-root")
+	}
+
+	var invalid int
+	now := time.Now()
+
+	if len(x5cRoots) > 0 {
+		rootBytes, err := readX5CRoots(x5cRoots)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Found %d X5C root certificates:\n", len(rootBytes))
+		for _, b := range rootBytes {
+			block, _ := pem.Decode(b)
+			crt, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return errors.Wrap(err, "error parsing certificate")
+			}
+			if !printRootSummary(crt.Subject.String(), crt.NotBefore, crt.NotAfter, now) {
+				invalid++
+			}
+		}
+	}
+
+	if len(nebulaRoots) > 0 {
+		rootBytes, err := readNebulaRoots(nebulaRoots)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Found %d Nebula CA certificates:\n", len(rootBytes))
+		for _, b := range rootBytes {
+			crt, _, err := nebula.UnmarshalNebulaCertificateFromPEM(b)
+			if err != nil {
+				return errors.Wrap(err, "error parsing certificate")
+			}
+			if !printRootSummary(crt.Details.Name, crt.Details.NotBefore, crt.Details.NotAfter, now) {
+				invalid++
+			}
+		}
+	}
+
+	if invalid > 0 {
+		return errs.NewExitError(errors.Errorf("%d root certificates are expired or not yet valid", invalid), invalidRootExitCode)
+	}
+	return nil
+}
+
+// printRootSummary prints the subject and validity of a root certificate. It
+// returns false if the certificate is expired or not yet valid at the given
+// time.
+func printRootSummary(subject string, notBefore, notAfter, now time.Time) bool {
+	status, valid := rootStatus(notBefore, notAfter, now)
+	fmt.Printf("  %s (%s)\n", subject, status)
+	return valid
+}
+
+// rootStatus returns the validity of a root certificate at the given time, and
+// false if it is expired or not yet valid.
+func rootStatus(notBefore, notAfter, now time.Time) (string, bool) {
+	switch {
+	case now.Before(notBefore):
+		return "NOT YET VALID until " + notBefore.UTC().Format(time.RFC3339), false
+	case now.After(notAfter):
+		return "EXPIRED " + notAfter.UTC().Format(time.RFC3339), false
+	default:
+		return "expires " + notAfter.UTC().Format(time.RFC3339), true
+	}
+}
@@ -0,0 +1,70 @@
+package provisionerbeta
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestRootStatus(t *testing.T) {
+	now := time.Now()
+	notBefore, notAfter := now.Add(-time.Hour), now.Add(time.Hour)
+
+	_, valid := rootStatus(notBefore, notAfter, now)
+	assert.True(t, valid)
+	status, valid := rootStatus(notBefore, notAfter, notAfter.Add(time.Minute))
+	assert.False(t, valid)
+	assert.Contains(t, status, "EXPIRED")
+	status, valid = rootStatus(notBefore, notAfter, notBefore.Add(-time.Minute))
+	assert.False(t, valid)
+	assert.Contains(t, status, "NOT YET VALID")
+}
+
+func TestValidateRootsAction_expired(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	now := time.Now()
+	writeRoot := func(name string, notBefore, notAfter time.Time) string {
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		require.NoError(t, err)
+		filename := filepath.Join(t.TempDir(), name+".crt")
+		require.NoError(t, os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+		return filename
+	}
+
+	valid := writeRoot("valid", now.Add(-time.Hour), now.Add(time.Hour))
+	expired := writeRoot("expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	notYetValid := writeRoot("not-yet-valid", now.Add(time.Hour), now.Add(2*time.Hour))
+
+	ctx := newTestContext(t, validateRootsCommand().Flags, []string{"--x5c-root", valid})
+	assert.NoError(t, validateRootsAction(ctx))
+
+	for _, filename := range []string{expired, notYetValid} {
+		ctx := newTestContext(t, validateRootsCommand().Flags, []string{"--x5c-root", valid, "--x5c-root", filename})
+		err := validateRootsAction(ctx)
+		var exitErr cli.ExitCoder
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, invalidRootExitCode, exitErr.ExitCode())
+	}
+}
@@ -0,0 +1,234 @@
+package provisionerbeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/smallstep/certificates/ca"
+	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/jose"
+	"github.com/smallstep/cli/utils/cautils"
+	"github.com/smallstep/cli/utils/errs"
+	"github.com/urfave/cli"
+	"go.step.sm/linkedca"
+)
+
+func verifyTokenCommand() cli.Command {
+	return cli.Command{
+		Name:   "verify-token",
+		Action: cli.ActionFunc(verifyTokenAction),
+		Usage:  "verify a provisioning token using the provisioner configuration",
+		UsageText: `**step beta ca provisioner verify-token** <name> <token>
+[**--admin-cert**=<file>] [**--admin-key**=<file>] [**--admin-provisioner**=<name>]
+[**--admin-subject**=<subject>] [**--password-file**=<file>] [**--password-command**=<command>]
+[**--ca-url**=<uri>] [**--root**=<file>] [**--context**=<name>]`,
+		Flags: []cli.Flag{
+			flags.AdminCert,
+			flags.AdminKey,
+			flags.AdminProvisioner,
+			flags.AdminSubject,
+			flags.PasswordFile,
+			flags.PasswordCommand,
+			flags.AdminRetry,
+			flags.AdminRetryBackoff,
+			flags.AdminTimeout,
+			flags.CaURL,
+			flags.Root,
+			flags.Context,
+		},
+		Description: `**step beta ca provisioner verify-token** retrieves a provisioner from the CA
+and verifies locally a provisioning token against it, printing the result of
+each check. This command helps to find out why the CA rejects a token.
+
+Only JWK provisioners are currently supported. The checks performed are:
+
+* the key id (kid) in the token header matches the provisioner key.
+* the token signature can be verified with the provisioner public key.
+* the issuer (iss) is the provisioner name.
+* the audience (aud) is an endpoint of the CA in **--ca-url**.
+* the token has a subject (sub) and an id (jti).
+* the token is not expired (exp), and it is already valid (nbf, iat).
+
+## POSITIONAL ARGUMENTS
+
+<name>
+: The name of the provisioner.
+
+<token>
+: The provisioning token to verify.
+
+## EXAMPLES
+
+Verify a token generated for the "ci" provisioner:
+'''
+$ TOKEN=$(step ca token --provisioner ci example.com)
+$ step beta ca provisioner verify-token ci $TOKEN
+'''
+`,
+	}
+}
+
+func verifyTokenAction(ctx *cli.Context) error {
+	if err := errs.NumberOfArguments(ctx, 2); err != nil {
+		return err
+	}
+
+	args := ctx.Args()
+	name, token := args.Get(0), args.Get(1)
+
+	caURL, err := flags.ParseCaURLIfExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	client, err := cautils.NewAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	p, err := client.GetProvisioner(ca.WithProvisionerName(name))
+	if err != nil {
+		return notFoundExitError(err)
+	}
+
+	checks, err := verifyJWKToken(p, token, caURL, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var failed bool
+	for _, c := range checks {
+		if c.err != nil {
+			failed = true
+			fmt.Printf("✖ %s: %v\n", c.name, c.err)
+		} else {
+			fmt.Printf("✔ %s\n", c.name)
+		}
+	}
+	if failed {
+		return errors.New("token verification failed")
+	}
+	return nil
+}
+
+// tokenCheck is the result of one of the validations done by
+// verify-token.
+type tokenCheck struct {
+	name string
+	err  error
+}
+
+// verifyTokenLeeway is the leeway used to validate the time claims, it
+// matches the one used by the CA.
+const verifyTokenLeeway = time.Minute
+
+// verifyJWKToken validates the given token using the configuration of a JWK
+// provisioner and returns the result of each check. An error is returned if
+// the provisioner is not a JWK provisioner or the token cannot be parsed.
+func verifyJWKToken(p *linkedca.Provisioner, token, caURL string, now time.Time) ([]tokenCheck, error) {
+	data, ok := p.Details.GetData().(*linkedca.ProvisionerDetails_JWK)
+	if !ok {
+		return nil, errors.Errorf("provisioner %s is not a JWK provisioner, only JWK provisioners are supported", p.Name)
+	}
+	var jwk jose.JSONWebKey
+	if err := json.Unmarshal(data.JWK.PublicKey, &jwk); err != nil {
+		return nil, errors.Wrap(err, "error parsing provisioner public key")
+	}
+
+	tok, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing token")
+	}
+	if len(tok.Headers) != 1 {
+		return nil, errors.New("error parsing token: multiple signatures are not supported")
+	}
+
+	var checks []tokenCheck
+	check := func(name string, err error) {
+		checks = append(checks, tokenCheck{name: name, err: err})
+	}
+
+	// Key id
+	if kid := tok.Headers[0].KeyID; kid != jwk.KeyID {
+		check("key id (kid)", errors.Errorf("expected %q, got %q", jwk.KeyID, kid))
+	} else {
+		check("key id (kid)", nil)
+	}
+
+	// Signature, the rest of the checks are not reliable if it fails.
+	var claims jose.Claims
+	if err := tok.Claims(jwk.Public(), &claims); err != nil {
+		if errors.Is(err, jose.ErrCryptoFailure) {
+			err = errors.New("the token is not signed by the provisioner key")
+		}
+		check("signature", err)
+		return checks, nil
+	}
+	check("signature", nil)
+
+	// Issuer
+	if claims.Issuer != p.Name {
+		check("issuer (iss)", errors.Errorf("expected %q, got %q", p.Name, claims.Issuer))
+	} else {
+		check("issuer (iss)", nil)
+	}
+
+	// Audience
+	switch {
+	case len(claims.Audience) == 0:
+		check("audience (aud)", errors.New("the token has no audience"))
+	case caURL == "":
+		check("audience (aud)", errors.New("cannot be verified without the '--ca-url' flag"))
+	default:
+		var found bool
+		prefix := strings.TrimSuffix(caURL, "/") + "/"
+		for _, aud := range claims.Audience {
+			if strings.HasPrefix(aud, prefix) {
+				found = true
+				break
+			}
+		}
+		if found {
+			check("audience (aud)", nil)
+		} else {
+			check("audience (aud)", errors.Errorf("%s is not an endpoint of %s", strings.Join(claims.Audience, ", "), caURL))
+		}
+	}
+
+	// Subject and id
+	if claims.Subject == "" {
+		check("subject (sub)", errors.New("the token has no subject"))
+	} else {
+		check("subject (sub)", nil)
+	}
+	if claims.ID == "" {
+		check("id (jti)", errors.New("the token has no id"))
+	} else {
+		check("id (jti)", nil)
+	}
+
+	// Time claims
+	switch {
+	case claims.Expiry == nil:
+		check("expiration (exp)", errors.New("the token has no expiration"))
+	case now.Add(-verifyTokenLeeway).After(claims.Expiry.Time()):
+		check("expiration (exp)", errors.Errorf("the token expired at %s", claims.Expiry.Time().Format(time.RFC3339)))
+	default:
+		check("expiration (exp)", nil)
+	}
+	if claims.NotBefore != nil && now.Add(verifyTokenLeeway).Before(claims.NotBefore.Time()) {
+		check("not before (nbf)", errors.Errorf("the token is not valid until %s", claims.NotBefore.Time().Format(time.RFC3339)))
+	} else {
+		check("not before (nbf)", nil)
+	}
+	if claims.IssuedAt != nil && now.Add(verifyTokenLeeway).Before(claims.IssuedAt.Time()) {
+		check("issued at (iat)", errors.Errorf("the token is issued in the future, at %s", claims.IssuedAt.Time().Format(time.RFC3339)))
+	} else {
+		check("issued at (iat)", nil)
+	}
+
+	return checks, nil
+}
@@ -0,0 +1,100 @@
+package provisionerbeta
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/smallstep/cli/jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.step.sm/linkedca"
+)
+
+func TestVerifyJWKToken(t *testing.T) {
+	jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	require.NoError(t, err)
+	jwk.KeyID, err = jose.Thumbprint(jwk)
+	require.NoError(t, err)
+	other, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	require.NoError(t, err)
+	other.KeyID = jwk.KeyID
+
+	pub, err := json.Marshal(jwk.Public())
+	require.NoError(t, err)
+	p := &linkedca.Provisioner{
+		Name: "ci",
+		Type: linkedca.Provisioner_JWK,
+		Details: &linkedca.ProvisionerDetails{
+			Data: &linkedca.ProvisionerDetails_JWK{
+				JWK: &linkedca.JWKProvisioner{PublicKey: pub},
+			},
+		},
+	}
+
+	now := time.Now()
+	newToken := func(key *jose.JSONWebKey, claims jose.Claims) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key.Key},
+			new(jose.SignerOptions).WithType("JWT").WithHeader("kid", key.KeyID))
+		require.NoError(t, err)
+		tok, err := jose.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+		return tok
+	}
+	validClaims := func() jose.Claims {
+		return jose.Claims{
+			Issuer:    "ci",
+			Subject:   "example.com",
+			Audience:  jose.Audience{"https://ca.example.com/1.0/sign"},
+			ID:        "the-id",
+			IssuedAt:  jose.NewNumericDate(now),
+			NotBefore: jose.NewNumericDate(now),
+			Expiry:    jose.NewNumericDate(now.Add(5 * time.Minute)),
+		}
+	}
+	failed := func(checks []tokenCheck) []string {
+		var names []string
+		for _, c := range checks {
+			if c.err != nil {
+				names = append(names, c.name)
+			}
+		}
+		return names
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		checks, err := verifyJWKToken(p, newToken(jwk, validClaims()), "https://ca.example.com", now)
+		require.NoError(t, err)
+		assert.Len(t, checks, 9)
+		assert.Empty(t, failed(checks))
+	})
+
+	t.Run("wrong claims", func(t *testing.T) {
+		claims := validClaims()
+		claims.Issuer = "other"
+		claims.Audience = jose.Audience{"https://other.example.com/1.0/sign"}
+		claims.Expiry = jose.NewNumericDate(now.Add(-time.Hour))
+		checks, err := verifyJWKToken(p, newToken(jwk, claims), "https://ca.example.com", now)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"issuer (iss)", "audience (aud)", "expiration (exp)"}, failed(checks))
+	})
+
+	t.Run("wrong signature", func(t *testing.T) {
+		checks, err := verifyJWKToken(p, newToken(other, validClaims()), "https://ca.example.com", now)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"signature"}, failed(checks))
+	})
+
+	t.Run("not JWK", func(t *testing.T) {
+		_, err := verifyJWKToken(&linkedca.Provisioner{
+			Name:    "acme",
+			Details: &linkedca.ProvisionerDetails{Data: &linkedca.ProvisionerDetails_ACME{ACME: &linkedca.ACMEProvisioner{}}},
+		}, newToken(jwk, validClaims()), "", now)
+		assert.Error(t, err)
+	})
+
+	t.Run("bad token", func(t *testing.T) {
+		_, err := verifyJWKToken(p, "not-a-token", "", now)
+		assert.Error(t, err)
+	})
+}
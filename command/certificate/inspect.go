@@ -13,9 +13,9 @@ import (
 	"github.com/smallstep/certinfo"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	zx509 "github.com/smallstep/zcrypto/x509"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func inspectCommand() cli.Command {
@@ -7,10 +7,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/smallstep/certinfo"
 	"github.com/smallstep/cli/crypto/pemutil"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/smallstep/truststore"
 	"github.com/urfave/cli"
 	"go.step.sm/cli-utils/command"
-	"go.step.sm/cli-utils/errs"
 )
 
 func installCommand() cli.Command {
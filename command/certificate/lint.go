@@ -7,10 +7,10 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils/errs"
 	zx509 "github.com/smallstep/zcrypto/x509"
 	"github.com/smallstep/zlint"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func lintCommand() cli.Command {
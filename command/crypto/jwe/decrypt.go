@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 	"go.step.sm/cli-utils/ui"
 
 	"github.com/pkg/errors"
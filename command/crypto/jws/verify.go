@@ -7,8 +7,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/smallstep/cli/jose"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func verifyCommand() cli.Command {
@@ -14,9 +14,9 @@ import (
 	"github.com/smallstep/cli/crypto/fingerprint"
 	"github.com/smallstep/cli/crypto/pemutil"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
 	libcommand "go.step.sm/cli-utils/command"
-	"go.step.sm/cli-utils/errs"
 	"golang.org/x/crypto/ssh"
 )
 
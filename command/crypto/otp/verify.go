@@ -13,8 +13,8 @@ import (
 	"github.com/pquerna/otp/totp"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func verifyCommand() cli.Command {
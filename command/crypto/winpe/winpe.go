@@ -10,9 +10,9 @@ import (
 	"path"
 
 	"github.com/pkg/errors"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
 	"go.mozilla.org/pkcs7"
-	"go.step.sm/cli-utils/errs"
 )
 
 // Command returns the winpe subcommand.
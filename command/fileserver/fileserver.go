@@ -11,7 +11,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/smallstep/cli/utils"
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 
 	"github.com/urfave/cli"
 	"go.step.sm/cli-utils/command"
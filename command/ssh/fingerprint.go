@@ -7,9 +7,9 @@ import (
 	libfingerprint "github.com/smallstep/cli/crypto/fingerprint"
 	"github.com/smallstep/cli/crypto/sshutil"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
 	libcommand "go.step.sm/cli-utils/command"
-	"go.step.sm/cli-utils/errs"
 )
 
 func fingerPrintCommand() cli.Command {
@@ -5,9 +5,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/smallstep/cli/crypto/sshutil"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
 	"go.step.sm/cli-utils/command"
-	"go.step.sm/cli-utils/errs"
 )
 
 func listCommand() cli.Command {
@@ -9,8 +9,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/smallstep/cli/crypto/sshutil"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -23,7 +23,7 @@ import (
 	"github.com/smallstep/cli/crypto/randutil"
 	"github.com/smallstep/cli/pkg/bcrypt_pbkdf"
 	"github.com/smallstep/cli/utils"
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 	"go.step.sm/cli-utils/ui"
 	"golang.org/x/crypto/ssh"
 )
@@ -13,7 +13,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/smallstep/cli/crypto/fingerprint"
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 )
 
 // Fingerprint returns the SHA-256 fingerprint of the certificate.
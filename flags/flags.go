@@ -12,8 +12,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/smallstep/certificates/api"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/step"
 )
 
@@ -103,6 +103,43 @@ unset, default is P-256 for EC keys and Ed25519 for OKP keys.
 		Usage: `The path to the <file> containing the password to encrypt or decrypt the private key.`,
 	}
 
+	// PasswordCommand is a cli.Flag used to pass a command that prints the
+	// password to encrypt or decrypt a private key.
+	PasswordCommand = cli.StringFlag{
+		Name: "password-command",
+		Usage: `The <command> that prints the password to encrypt or decrypt the private key.
+The command is run using the system shell and its output is used as the password.`,
+	}
+
+	// AdminRetry is a cli.Flag used to set the number of times a request to the
+	// admin API is retried.
+	AdminRetry = cli.IntFlag{
+		Name: "retry",
+		Usage: `The number of times a request to the admin API is retried if it fails with a
+connection error or a 5xx status code. Requests failing with a 4xx status code
+are never retried. The retries are printed to STDERR if the command supports
+and uses **--verbose**.`,
+	}
+
+	// AdminRetryBackoff is a cli.Flag used to set the time to wait before the
+	// first retry of a request to the admin API.
+	AdminRetryBackoff = cli.DurationFlag{
+		Name: "retry-backoff",
+		Usage: `The <duration> to wait before retrying a failed request to the admin API. The
+duration is doubled on each retry.`,
+		Value: 500 * time.Millisecond,
+	}
+
+	// AdminTimeout is a cli.Flag used to set the maximum time a request to the
+	// admin API can take.
+	AdminTimeout = cli.DurationFlag{
+		Name: "timeout",
+		Usage: `The maximum <duration> of a request to the admin API, including its retries
+and the time spent reading the response. Use 0 to disable the timeout. If set,
+it is also used when retrieving an OpenID Connect discovery document.`,
+		Value: 30 * time.Second,
+	}
+
 	// NoPassword is a cli.Flag used to avoid using a password to encrypt private
 	// keys.
 	NoPassword = cli.BoolFlag{
@@ -160,15 +197,21 @@ as "300ms", "-1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms"
 
 	// AdminProvisioner is a cli.Flag used to pass the CA Admin provisioner to use.
 	AdminProvisioner = cli.StringFlag{
-		Name:  "admin-provisioner,admin-issuer",
-		Usage: "The provisioner <name> to use for generating admin credentials.",
+		Name:   "admin-provisioner,admin-issuer",
+		EnvVar: "STEP_ADMIN_PROVISIONER",
+		Usage: `The provisioner <name> to use for generating admin credentials.
+If not set, the value of the STEP_ADMIN_PROVISIONER environment variable is
+used, followed by the "admin-provisioner" value in the defaults file.`,
 	}
 
 	// AdminSubject is a cli.Flag used to pass the admin subject to use when generating
 	// admin credentials.
 	AdminSubject = cli.StringFlag{
-		Name:  "admin-subject,admin-name",
-		Usage: "The admin <subject> to use for generating admin credentials.",
+		Name:   "admin-subject,admin-name",
+		EnvVar: "STEP_ADMIN_SUBJECT",
+		Usage: `The admin <subject> to use for generating admin credentials.
+If not set, the value of the STEP_ADMIN_SUBJECT environment variable is used,
+followed by the "admin-subject" value in the defaults file.`,
 	}
 
 	// ProvisionerPasswordFile is a cli.Flag used to pass the password file to
@@ -243,8 +286,9 @@ $(step path)/config/ca.json`,
 
 	// AdminCert is a cli.Flag used to pass the x5c header certificate for a JWT.
 	AdminCert = cli.StringFlag{
-		Name:  "admin-cert",
-		Usage: "Admin certificate (<chain>) in PEM format to store in the 'x5c' header of a JWT.",
+		Name: "admin-cert",
+		Usage: `Admin certificate (<chain>) in PEM format to store in the 'x5c' header of a JWT.
+The certificate is also used as the client certificate if the CA requires mutual TLS.`,
 	}
 
 	// AdminKey is a cli.Flag used to pass the private key (corresponding to the x5c-cert)
@@ -252,7 +296,7 @@ $(step path)/config/ca.json`,
 	AdminKey = cli.StringFlag{
 		Name: "admin-key",
 		Usage: `Private key <file>, used to sign a JWT, corresponding to the admin certificate that will
-be stored in the 'x5c' header.`,
+be stored in the 'x5c' header. It is also used as the client key if the CA requires mutual TLS.`,
 	}
 
 	// X5cCert is a cli.Flag used to pass the x5c header certificate for a JWT.
@@ -109,3 +109,33 @@ func Test_parseCaURL(t *testing.T) {
 		})
 	}
 }
+
+func TestAdminFlagsEnvVar(t *testing.T) {
+	t.Setenv("STEP_ADMIN_PROVISIONER", "env-provisioner")
+	t.Setenv("STEP_ADMIN_SUBJECT", "env@example.com")
+
+	tests := []struct {
+		name                 string
+		args                 []string
+		provisioner, subject string
+	}{
+		{"env", nil, "env-provisioner", "env@example.com"},
+		{"flags", []string{"--admin-provisioner", "admin", "--admin-subject", "admin@example.com"}, "admin", "admin@example.com"},
+		{"aliases", []string{"--admin-issuer", "admin", "--admin-name", "admin@example.com"}, "admin", "admin@example.com"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var provisioner, subject string
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{AdminProvisioner, AdminSubject}
+			app.Action = func(ctx *cli.Context) error {
+				provisioner = ctx.String("admin-provisioner")
+				subject = ctx.String("admin-subject")
+				return nil
+			}
+			assert.FatalError(t, app.Run(append([]string{"step"}, tc.args...)))
+			assert.Equals(t, tc.provisioner, provisioner)
+			assert.Equals(t, tc.subject, subject)
+		})
+	}
+}
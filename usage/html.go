@@ -7,8 +7,8 @@ import (
 	"path"
 	"strings"
 
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 )
 
 func httpHelpAction(ctx *cli.Context) error {
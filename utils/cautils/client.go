@@ -1,9 +1,12 @@
 package cautils
 
 import (
+	"crypto"
 	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"io"
 	"net/http"
 	"os"
 	"time"
@@ -16,8 +19,9 @@ import (
 	"github.com/smallstep/cli/crypto/keys"
 	"github.com/smallstep/cli/crypto/pemutil"
 	"github.com/smallstep/cli/flags"
+	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
 )
 
@@ -71,6 +75,13 @@ func NewClient(ctx *cli.Context, opts ...ca.ClientOption) (CaClient, error) {
 }
 
 // NewAdminClient returns a client for the mgmt API of the online CA.
+//
+// If no admin certificate is given, new admin credentials are generated using
+// the provisioner in --admin-provisioner and the subject in --admin-subject.
+// Like any other flag, they default to the STEP_ADMIN_PROVISIONER and
+// STEP_ADMIN_SUBJECT environment variables, and then to the defaults file; the
+// provisioner password is read in the same way from --password-file or
+// STEP_PASSWORD_FILE. Flags always take precedence.
 func NewAdminClient(ctx *cli.Context, opts ...ca.ClientOption) (*ca.AdminClient, error) {
 	caURL, err := flags.ParseCaURLIfExists(ctx)
 	if err != nil {
@@ -87,6 +98,10 @@ func NewAdminClient(ctx *cli.Context, opts ...ca.ClientOption) (*ca.AdminClient,
 		}
 	}
 
+	if ctx.String("password-file") != "" && ctx.String("password-command") != "" {
+		return nil, errs.MutuallyExclusiveFlags(ctx, "password-file", "password-command")
+	}
+
 	var (
 		adminCertFile = ctx.String("admin-cert")
 		adminKeyFile  = ctx.String("admin-key")
@@ -104,10 +119,23 @@ func NewAdminClient(ctx *cli.Context, opts ...ca.ClientOption) (*ca.AdminClient,
 		if err != nil {
 			return nil, errors.Wrap(err, "error reading admin certificate")
 		}
-		adminKey, err = pemutil.Read(adminKeyFile)
+		var (
+			keyOpts []pemutil.Options
+			pass    []byte
+		)
+		if pass, err = readAdminPassword(ctx); err != nil {
+			return nil, err
+		}
+		if pass != nil {
+			keyOpts = append(keyOpts, pemutil.WithPassword(pass))
+		}
+		adminKey, err = pemutil.Read(adminKeyFile, keyOpts...)
 		if err != nil {
 			return nil, errors.Wrap(err, "error reading admin key")
 		}
+		if err := validateAdminKeyPair(adminCert, adminKey); err != nil {
+			return nil, err
+		}
 	} else {
 		ui.Printf("No admin credentials found. You must login to execute admin commands.\n")
 		// Generate a new admin cert/key in memory.
@@ -171,9 +199,84 @@ func NewAdminClient(ctx *cli.Context, opts ...ca.ClientOption) (*ca.AdminClient,
 		}
 	}
 
-	// Create online client
-	opts = append([]ca.ClientOption{ca.WithRootFile(root),
-		ca.WithAdminX5C(adminCert, adminKey, ctx.String("password-file"))},
+	// Create online client, the admin certificate is also used as the TLS
+	// client certificate for deployments using mutual TLS.
+	tlsCert := adminTLSCertificate(adminCert, adminKey)
+	transportOpts := []ca.ClientOption{ca.WithRootFile(root), ca.WithCertificate(tlsCert)}
+	retries := ctx.Int("retry")
+	if retries < 0 {
+		return nil, errs.MinSizeFlag(ctx, "retry", "0")
+	}
+	timeout := ctx.Duration("timeout")
+	if timeout < 0 {
+		return nil, errs.InvalidFlagValueMsg(ctx, "timeout", timeout.String(), "value must not be negative")
+	}
+	if retries > 0 || timeout > 0 {
+		var tr http.RoundTripper
+		if tr, err = newAdminTransport(root, tlsCert); err != nil {
+			return nil, err
+		}
+		if retries > 0 {
+			// Retries are only printed with verbose output.
+			var out io.Writer = io.Discard
+			if ctx.Bool("verbose") {
+				out = os.Stderr
+			}
+			tr = &retryTransport{
+				next:    tr,
+				retries: retries,
+				backoff: ctx.Duration("retry-backoff"),
+				out:     out,
+			}
+		}
+		// The timeout wraps the retries, so it bounds the whole request.
+		if timeout > 0 {
+			tr = &timeoutTransport{next: tr, timeout: timeout}
+		}
+		transportOpts = []ca.ClientOption{ca.WithTransport(tr)}
+	}
+	opts = append(append(transportOpts,
+		ca.WithAdminX5C(adminCert, adminKey, "")),
 		opts...)
 	return ca.NewAdminClient(caURL, opts...)
 }
+
+// validateAdminKeyPair checks that the admin key is the private key of the
+// leaf certificate in the admin certificate chain.
+func validateAdminKeyPair(certs []*x509.Certificate, key interface{}) error {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return errors.Errorf("error reading admin key: key type %T is not supported", key)
+	}
+	pub, ok := certs[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(signer.Public()) {
+		return errors.New("admin certificate and admin key do not match")
+	}
+	return nil
+}
+
+// readAdminPassword returns the password used to decrypt the admin key, read
+// from --password-file or --password-command, or nil if none is set.
+func readAdminPassword(ctx *cli.Context) ([]byte, error) {
+	switch {
+	case ctx.String("password-file") != "":
+		return utils.ReadPasswordFile(ctx.String("password-file"))
+	case ctx.String("password-command") != "":
+		return utils.ReadPasswordFromCommand(ctx.String("password-command"))
+	default:
+		return nil, nil
+	}
+}
+
+// adminTLSCertificate returns the admin certificate chain and key as a
+// tls.Certificate.
+func adminTLSCertificate(certs []*x509.Certificate, key interface{}) tls.Certificate {
+	crt := tls.Certificate{
+		PrivateKey: key,
+		Leaf:       certs[0],
+	}
+	for _, c := range certs {
+		crt.Certificate = append(crt.Certificate, c.Raw)
+	}
+	return crt
+}
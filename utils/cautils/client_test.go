@@ -0,0 +1,73 @@
+package cautils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli"
+	"go.step.sm/crypto/jose"
+)
+
+func TestPasswordFile_trailingNewLine(t *testing.T) {
+	dir := t.TempDir()
+	jwk, err := jose.GenerateJWK("EC", "P-256", "ES256", "sig", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwe, err := jose.EncryptJWK(jwk, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := jwe.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key.jwe")
+	if err := os.WriteFile(keyFile, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"with new line", "password\n", "password"},
+		{"without new line", "password", "password"},
+		{"with trailing space", "password \n", "password "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passwordFile := filepath.Join(dir, "password.txt")
+			if err := os.WriteFile(passwordFile, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			set := flag.NewFlagSet("test", 0)
+			set.String("password-file", passwordFile, "")
+			set.String("password-command", "", "")
+			set.String("provisioner-password-file", "", "")
+			ctx := cli.NewContext(&cli.App{}, set, nil)
+
+			// Admin key password.
+			pass, err := readAdminPassword(ctx)
+			if err != nil {
+				t.Fatalf("readAdminPassword() error = %v", err)
+			}
+			if string(pass) != tt.want {
+				t.Errorf("readAdminPassword() = %q, want %q", pass, tt.want)
+			}
+
+			// Provisioner key password.
+			opt, err := getProvisionerPasswordOption(ctx)
+			if err != nil {
+				t.Fatalf("getProvisionerPasswordOption() error = %v", err)
+			}
+			_, err = jose.ReadKey(keyFile, opt)
+			if wantOK := tt.want == "password"; (err == nil) != wantOK {
+				t.Errorf("jose.ReadKey() error = %v, want success %v", err, wantOK)
+			}
+		})
+	}
+}
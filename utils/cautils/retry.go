@@ -0,0 +1,84 @@
+package cautils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/smallstep/cli/crypto/x509util"
+)
+
+// retryTransport is an http.RoundTripper that retries the requests failing
+// with a connection error or a 5xx status code.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+	out     io.Writer
+}
+
+// newAdminTransport returns an http.RoundTripper trusting the given root
+// certificates and using the given client certificate.
+func newAdminTransport(root string, cert tls.Certificate) (http.RoundTripper, error) {
+	pool, err := x509util.ReadCertPool(root)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			RootCAs:      pool,
+			Certificates: []tls.Certificate{cert},
+		},
+	}, nil
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(r)
+
+		var reason string
+		switch {
+		case err != nil:
+			reason = err.Error()
+		case resp.StatusCode >= 500:
+			reason = resp.Status
+		default:
+			return resp, nil
+		}
+		// Requests with a body can only be retried if it can be read again.
+		if attempt > t.retries || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		wait := t.backoff << (attempt - 1)
+		fmt.Fprintf(t.out, "%s %s failed: %s; retrying in %s (%d/%d)\n",
+			req.Method, req.URL, reason, wait, attempt, t.retries)
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		r = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if r.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cautils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		retries   int
+		wantCode  int
+		wantCalls int
+	}{
+		{"ok", []int{200}, 3, 200, 1},
+		{"retry 5xx", []int{503, 502, 200}, 3, 200, 3},
+		{"no retry 4xx", []int{404, 200}, 3, 404, 1},
+		{"too many 5xx", []int{500, 500, 500}, 2, 500, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				if err != nil || string(b) != "body" {
+					t.Errorf("unexpected body %q, error %v", b, err)
+				}
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: &retryTransport{
+				next:    http.DefaultTransport,
+				retries: tt.retries,
+				out:     io.Discard,
+			}}
+			resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("body"))
+			if err != nil {
+				t.Fatalf("client.Post() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cautils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// timeoutTransport is an http.RoundTripper that cancels the requests, and the
+// reading of their responses, after the given timeout.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip implements the http.RoundTripper interface. Requests cancelled by
+// the timeout fail with an error wrapping context.DeadlineExceeded.
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, errors.Wrapf(context.DeadlineExceeded, "%s %s timed out after %s", req.Method, req.URL, t.timeout)
+		}
+		return nil, err
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, timeout: t.timeout}
+	return resp, nil
+}
+
+// timeoutBody is the body of a response of a timeoutTransport, it releases the
+// request context when it is closed.
+type timeoutBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && errors.Is(b.ctx.Err(), context.DeadlineExceeded) {
+		return n, errors.Wrapf(context.DeadlineExceeded, "reading response timed out after %s", b.timeout)
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
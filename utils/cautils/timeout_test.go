@@ -0,0 +1,60 @@
+package cautils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		delay       time.Duration
+		wantTimeout bool
+	}{
+		{"ok", 0, false},
+		{"timeout", time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.delay):
+				case <-done:
+				}
+				io.WriteString(w, "ok")
+			}))
+			defer srv.Close()
+			defer close(done)
+
+			client := &http.Client{Transport: &timeoutTransport{
+				next:    http.DefaultTransport,
+				timeout: 100 * time.Millisecond,
+			}}
+			resp, err := client.Get(srv.URL)
+			if tt.wantTimeout {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("client.Get() error = %v, want context.DeadlineExceeded", err)
+				}
+				if !strings.Contains(err.Error(), "timed out after 100ms") {
+					t.Errorf("client.Get() error = %v, want timeout message", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("client.Get() error = %v", err)
+			}
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			if err != nil || string(b) != "ok" {
+				t.Errorf("io.ReadAll() = %q, %v, want \"ok\"", b, err)
+			}
+		})
+	}
+}
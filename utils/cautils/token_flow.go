@@ -11,8 +11,8 @@ import (
 	"github.com/smallstep/certificates/pki"
 	"github.com/smallstep/cli/flags"
 	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
 )
 
@@ -396,3 +396,20 @@ func provisionerFilter(provisioners provisioner.List, f func(provisioner.Interfa
 	}
 	return result
 }
+
+// LookupProvisioner returns the provisioner with the given name or, if no
+// provisioner has that name, the JWK provisioner with the given key id. The
+// returned byName is true if the provisioner was found by its name.
+func LookupProvisioner(provisioners provisioner.List, nameOrKid string) (p provisioner.Interface, byName, ok bool) {
+	for _, p := range provisioners {
+		if p.GetName() == nameOrKid {
+			return p, true, true
+		}
+	}
+	for _, p := range provisioners {
+		if jwk, isJWK := p.(*provisioner.JWK); isJWK && jwk.Key != nil && jwk.Key.KeyID == nameOrKid {
+			return p, false, true
+		}
+	}
+	return nil, false, false
+}
@@ -0,0 +1,38 @@
+package cautils
+
+import (
+	"testing"
+
+	"github.com/smallstep/certificates/authority/provisioner"
+	"go.step.sm/crypto/jose"
+)
+
+func TestLookupProvisioner(t *testing.T) {
+	admin := &provisioner.JWK{Name: "admin", Key: &jose.JSONWebKey{KeyID: "admin-kid"}}
+	ci := &provisioner.JWK{Name: "ci", Key: &jose.JSONWebKey{KeyID: "ci-kid"}}
+	confusing := &provisioner.JWK{Name: "ci-kid", Key: &jose.JSONWebKey{KeyID: "other-kid"}}
+	acme := &provisioner.ACME{Name: "acme"}
+	provisioners := provisioner.List{admin, ci, acme, confusing}
+
+	tests := []struct {
+		name       string
+		nameOrKid  string
+		want       provisioner.Interface
+		wantByName bool
+		wantOK     bool
+	}{
+		{"by name", "admin", admin, true, true},
+		{"by kid", "admin-kid", admin, false, true},
+		{"name before kid", "ci-kid", confusing, true, true},
+		{"not JWK", "acme", acme, true, true},
+		{"not found", "missing", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, byName, ok := LookupProvisioner(provisioners, tt.nameOrKid)
+			if got != tt.want || byName != tt.wantByName || ok != tt.wantOK {
+				t.Errorf("LookupProvisioner() = %v, %v, %v, want %v, %v, %v", got, byName, ok, tt.want, tt.wantByName, tt.wantOK)
+			}
+		})
+	}
+}
@@ -18,8 +18,9 @@ import (
 	"github.com/smallstep/cli/exec"
 	"github.com/smallstep/cli/token"
 	"github.com/smallstep/cli/token/provision"
+	"github.com/smallstep/cli/utils"
+	"github.com/smallstep/cli/utils/errs"
 	"github.com/urfave/cli"
-	"go.step.sm/cli-utils/errs"
 	"go.step.sm/cli-utils/ui"
 	"go.step.sm/crypto/jose"
 	"go.step.sm/crypto/x25519"
@@ -170,7 +171,11 @@ func generateX5CToken(ctx *cli.Context, p *provisioner.X5C, tokType int, tokAttr
 
 	// Get private key from given key file
 	var opts []jose.Option
-	if passOpt := getProvisionerPasswordOption(ctx); passOpt != nil {
+	passOpt, err := getProvisionerPasswordOption(ctx)
+	if err != nil {
+		return "", err
+	}
+	if passOpt != nil {
 		opts = append(opts, passOpt)
 	}
 	jwk, err := jose.ReadKey(x5cKeyFile, opts...)
@@ -265,7 +270,11 @@ func generateSSHPOPToken(ctx *cli.Context, p *provisioner.SSHPOP, tokType int, t
 
 	// Get private key from given key file
 	var opts []jose.Option
-	if passOpt := getProvisionerPasswordOption(ctx); passOpt != nil {
+	passOpt, err := getProvisionerPasswordOption(ctx)
+	if err != nil {
+		return "", err
+	}
+	if passOpt != nil {
 		opts = append(opts, passOpt)
 	}
 	jwk, err := jose.ReadKey(sshPOPKeyFile, opts...)
@@ -287,14 +296,28 @@ func generateSSHPOPToken(ctx *cli.Context, p *provisioner.SSHPOP, tokType int, t
 	}
 }
 
-func getProvisionerPasswordOption(ctx *cli.Context) jose.Option {
+func getProvisionerPasswordOption(ctx *cli.Context) (jose.Option, error) {
 	switch {
 	case ctx.String("provisioner-password-file") != "":
-		return jose.WithPasswordFile(ctx.String("provisioner-password-file"))
+		pass, err := utils.ReadPasswordFile(ctx.String("provisioner-password-file"))
+		if err != nil {
+			return nil, err
+		}
+		return jose.WithPassword(pass), nil
 	case ctx.String("password-file") != "":
-		return jose.WithPasswordFile(ctx.String("password-file"))
+		pass, err := utils.ReadPasswordFile(ctx.String("password-file"))
+		if err != nil {
+			return nil, err
+		}
+		return jose.WithPassword(pass), nil
+	case ctx.String("password-command") != "":
+		pass, err := utils.ReadPasswordFromCommand(ctx.String("password-command"))
+		if err != nil {
+			return nil, err
+		}
+		return jose.WithPassword(pass), nil
 	default:
-		return nil
+		return nil, nil
 	}
 }
 
@@ -307,7 +330,11 @@ func getProvisionerPasswordOption(ctx *cli.Context) jose.Option {
 //    b) Online-mode: get the provisioner private key from the CA.
 func loadJWK(ctx *cli.Context, p *provisioner.JWK, tokAttrs tokenAttrs) (jwk *jose.JSONWebKey, kid string, err error) {
 	var opts []jose.Option
-	if passOpt := getProvisionerPasswordOption(ctx); passOpt != nil {
+	passOpt, err := getProvisionerPasswordOption(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if passOpt != nil {
 		opts = append(opts, passOpt)
 	}
 
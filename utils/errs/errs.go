@@ -0,0 +1,307 @@
+// Package errs wraps the helpers in go.step.sm/cli-utils/errs. The errors in
+// the flags, in the positional arguments and in the files are returned as a
+// FlagError, an ArgumentError and a FileAccessError respectively, so they can
+// be classified with errors.As. Their messages are not modified.
+package errs
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+	"go.step.sm/cli-utils/errs"
+)
+
+// FriendlyError is an interface for returning friendly error messages to the
+// user.
+type FriendlyError = errs.FriendlyError
+
+// FlagError is an error in the flags of a command.
+type FlagError struct {
+	err error
+}
+
+func (e *FlagError) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *FlagError) Unwrap() error { return e.err }
+
+// Cause returns the wrapped error, it implements the causer interface of the
+// github.com/pkg/errors package.
+func (e *FlagError) Cause() error { return e.err }
+
+// Format implements fmt.Formatter, so the stack trace of the wrapped error is
+// printed with %+v.
+func (e *FlagError) Format(st fmt.State, verb rune) { format(st, verb, e.err) }
+
+// ArgumentError is an error in the positional arguments of a command.
+type ArgumentError struct {
+	err error
+}
+
+func (e *ArgumentError) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *ArgumentError) Unwrap() error { return e.err }
+
+// Cause returns the wrapped error, it implements the causer interface of the
+// github.com/pkg/errors package.
+func (e *ArgumentError) Cause() error { return e.err }
+
+// Format implements fmt.Formatter, so the stack trace of the wrapped error is
+// printed with %+v.
+func (e *ArgumentError) Format(st fmt.State, verb rune) { format(st, verb, e.err) }
+
+// FileAccessError is an error reading or writing a file, as returned by
+// FileError.
+type FileAccessError struct {
+	err error
+}
+
+func (e *FileAccessError) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *FileAccessError) Unwrap() error { return e.err }
+
+// Cause returns the wrapped error, it implements the causer interface of the
+// github.com/pkg/errors package.
+func (e *FileAccessError) Cause() error { return e.err }
+
+// Format implements fmt.Formatter, so the stack trace of the wrapped error is
+// printed with %+v.
+func (e *FileAccessError) Format(st fmt.State, verb rune) { format(st, verb, e.err) }
+
+func format(st fmt.State, verb rune, err error) {
+	if f, ok := err.(fmt.Formatter); ok {
+		f.Format(st, verb)
+		return
+	}
+	fmt.Fprint(st, err.Error())
+}
+
+func flagError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &FlagError{err: err}
+}
+
+func argumentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ArgumentError{err: err}
+}
+
+// NewError returns a new Error for the given format and arguments.
+func NewError(format string, args ...interface{}) error {
+	return errs.NewError(format, args...)
+}
+
+// NewExitError returns an error that the urfave/cli package will handle and
+// will show the given error and exit with the given code.
+func NewExitError(err error, exitCode int) error {
+	return errs.NewExitError(err, exitCode)
+}
+
+// Wrap returns a new error wrapped by the given error with the given message.
+// See errs.Wrap in go.step.sm/cli-utils.
+func Wrap(err error, format string, args ...interface{}) error {
+	return errs.Wrap(err, format, args...)
+}
+
+// InsecureCommand returns a FlagError saying that the current command
+// requires the insecure flag.
+func InsecureCommand(ctx *cli.Context) error {
+	return flagError(errs.InsecureCommand(ctx))
+}
+
+// EqualArguments returns an ArgumentError saying that the given positional
+// arguments cannot be equal.
+func EqualArguments(ctx *cli.Context, arg1, arg2 string) error {
+	return argumentError(errs.EqualArguments(ctx, arg1, arg2))
+}
+
+// MissingArguments returns an ArgumentError with a missing arguments message
+// for the given positional argument names.
+func MissingArguments(ctx *cli.Context, argNames ...string) error {
+	return argumentError(errs.MissingArguments(ctx, argNames...))
+}
+
+// NumberOfArguments returns nil if the number of positional arguments is
+// equal to the required one, or an ArgumentError otherwise.
+func NumberOfArguments(ctx *cli.Context, required int) error {
+	return argumentError(errs.NumberOfArguments(ctx, required))
+}
+
+// MinMaxNumberOfArguments returns nil if the number of positional arguments
+// is between the min/max range, or an ArgumentError otherwise.
+func MinMaxNumberOfArguments(ctx *cli.Context, min, max int) error {
+	return argumentError(errs.MinMaxNumberOfArguments(ctx, min, max))
+}
+
+// TooFewArguments returns an ArgumentError with a few arguments were provided
+// message.
+func TooFewArguments(ctx *cli.Context) error {
+	return argumentError(errs.TooFewArguments(ctx))
+}
+
+// TooManyArguments returns an ArgumentError with a too many arguments were
+// provided message.
+func TooManyArguments(ctx *cli.Context) error {
+	return argumentError(errs.TooManyArguments(ctx))
+}
+
+// InsecureArgument returns an ArgumentError with the given argument requiring
+// the --insecure flag.
+func InsecureArgument(ctx *cli.Context, name string) error {
+	return argumentError(errs.InsecureArgument(ctx, name))
+}
+
+// FlagValueInsecure returns a FlagError with the given flag and value
+// requiring the --insecure flag.
+func FlagValueInsecure(ctx *cli.Context, flag, value string) error {
+	return flagError(errs.FlagValueInsecure(ctx, flag, value))
+}
+
+// InvalidFlagValue returns a FlagError with the given value being missing or
+// invalid for the given flag. Optionally it lists the given formatted options
+// at the end.
+func InvalidFlagValue(ctx *cli.Context, flag, value, options string) error {
+	return flagError(errs.InvalidFlagValue(ctx, flag, value, options))
+}
+
+// InvalidFlagValueMsg returns a FlagError with the given value being missing
+// or invalid for the given flag. Optionally it adds the given message to aid
+// in debugging.
+func InvalidFlagValueMsg(ctx *cli.Context, flag, value, msg string) error {
+	return flagError(errs.InvalidFlagValueMsg(ctx, flag, value, msg))
+}
+
+// IncompatibleFlag returns a FlagError with the flag being incompatible with
+// the given value.
+func IncompatibleFlag(ctx *cli.Context, flag, value string) error {
+	return flagError(errs.IncompatibleFlag(ctx, flag, value))
+}
+
+// IncompatibleFlagWithFlag returns a FlagError with the flag being
+// incompatible with the given flag.
+func IncompatibleFlagWithFlag(ctx *cli.Context, flag, withFlag string) error {
+	return flagError(errs.IncompatibleFlagWithFlag(ctx, flag, withFlag))
+}
+
+// IncompatibleFlagValue returns a FlagError with the flag being incompatible
+// with the given flag and value.
+func IncompatibleFlagValue(ctx *cli.Context, flag, incompatibleWith, incompatibleWithValue string) error {
+	return flagError(errs.IncompatibleFlagValue(ctx, flag, incompatibleWith, incompatibleWithValue))
+}
+
+// IncompatibleFlagValues returns a FlagError with the flag and value being
+// incompatible with the given flag and value.
+func IncompatibleFlagValues(ctx *cli.Context, flag, value, incompatibleWith, incompatibleWithValue string) error {
+	return flagError(errs.IncompatibleFlagValues(ctx, flag, value, incompatibleWith, incompatibleWithValue))
+}
+
+// IncompatibleFlagValueWithFlagValue returns a FlagError with the flag and
+// value being incompatible with the given flag and value. Optionally it lists
+// the given formatted options at the end.
+func IncompatibleFlagValueWithFlagValue(ctx *cli.Context, flag, value, withFlag, withValue, options string) error {
+	return flagError(errs.IncompatibleFlagValueWithFlagValue(ctx, flag, value, withFlag, withValue, options))
+}
+
+// RequiredFlag returns a FlagError with the required flag message.
+func RequiredFlag(ctx *cli.Context, flag string) error {
+	return flagError(errs.RequiredFlag(ctx, flag))
+}
+
+// RequiredWithFlag returns a FlagError with the required flag message with
+// another flag.
+func RequiredWithFlag(ctx *cli.Context, flag, required string) error {
+	return flagError(errs.RequiredWithFlag(ctx, flag, required))
+}
+
+// RequiredWithFlagValue returns a FlagError with the required flag message
+// with another flag and value.
+func RequiredWithFlagValue(ctx *cli.Context, flag, value, required string) error {
+	return flagError(errs.RequiredWithFlagValue(ctx, flag, value, required))
+}
+
+// RequiredWithProvisionerTypeFlag returns a FlagError with the required flag
+// message with a provisioner type.
+func RequiredWithProvisionerTypeFlag(ctx *cli.Context, provisionerType, required string) error {
+	return flagError(errs.RequiredWithProvisionerTypeFlag(ctx, provisionerType, required))
+}
+
+// RequiredInsecureFlag returns a FlagError with the given flag requiring the
+// insecure flag message.
+func RequiredInsecureFlag(ctx *cli.Context, flag string) error {
+	return flagError(errs.RequiredInsecureFlag(ctx, flag))
+}
+
+// RequiredSubtleFlag returns a FlagError with the given flag requiring the
+// subtle flag message.
+func RequiredSubtleFlag(ctx *cli.Context, flag string) error {
+	return flagError(errs.RequiredSubtleFlag(ctx, flag))
+}
+
+// RequiredUnlessInsecureFlag returns a FlagError with the required flag
+// message unless the insecure flag is used.
+func RequiredUnlessInsecureFlag(ctx *cli.Context, flag string) error {
+	return flagError(errs.RequiredUnlessInsecureFlag(ctx, flag))
+}
+
+// RequiredUnlessFlag returns a FlagError with the required flag message
+// unless the specified flag is used.
+func RequiredUnlessFlag(ctx *cli.Context, flag, unlessFlag string) error {
+	return flagError(errs.RequiredUnlessFlag(ctx, flag, unlessFlag))
+}
+
+// RequiredUnlessSubtleFlag returns a FlagError with the required flag message
+// unless the subtle flag is used.
+func RequiredUnlessSubtleFlag(ctx *cli.Context, flag string) error {
+	return flagError(errs.RequiredUnlessSubtleFlag(ctx, flag))
+}
+
+// RequiredOrFlag returns a FlagError with a list of flags being required
+// message.
+func RequiredOrFlag(ctx *cli.Context, flags ...string) error {
+	return flagError(errs.RequiredOrFlag(ctx, flags...))
+}
+
+// RequiredWithOrFlag returns a FlagError with a list of flags at least one of
+// which is required in conjunction with the given flag.
+func RequiredWithOrFlag(ctx *cli.Context, withFlag string, flags ...string) error {
+	return flagError(errs.RequiredWithOrFlag(ctx, withFlag, flags...))
+}
+
+// MinSizeFlag returns a FlagError with a greater or equal message for the
+// given flag and size.
+func MinSizeFlag(ctx *cli.Context, flag, size string) error {
+	return flagError(errs.MinSizeFlag(ctx, flag, size))
+}
+
+// MinSizeInsecureFlag returns a FlagError with a requiring --insecure flag
+// message with the given flag and size.
+func MinSizeInsecureFlag(ctx *cli.Context, flag, size string) error {
+	return flagError(errs.MinSizeInsecureFlag(ctx, flag, size))
+}
+
+// MutuallyExclusiveFlags returns a FlagError with mutually exclusive message
+// for the given flags.
+func MutuallyExclusiveFlags(ctx *cli.Context, flag1, flag2 string) error {
+	return flagError(errs.MutuallyExclusiveFlags(ctx, flag1, flag2))
+}
+
+// UnsupportedFlag returns a FlagError with a message saying that the given
+// flag is not yet supported.
+func UnsupportedFlag(ctx *cli.Context, flag string) error {
+	return flagError(errs.UnsupportedFlag(ctx, flag))
+}
+
+// FileError returns a FileAccessError for the errors of the os package, or nil
+// if err is nil.
+func FileError(err error, filename string) error {
+	if err = errs.FileError(err, filename); err == nil {
+		return nil
+	}
+	return &FileAccessError{err: err}
+}
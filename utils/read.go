@@ -3,14 +3,18 @@ package utils
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"os"
+	"os/exec"
+	"runtime"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/pkg/errors"
 
-	"go.step.sm/cli-utils/errs"
+	"github.com/smallstep/cli/utils/errs"
 	"go.step.sm/cli-utils/ui"
 )
 
@@ -59,6 +63,54 @@ func ReadPasswordFromFile(filename string) ([]byte, error) {
 	return password, nil
 }
 
+// passwordCommandTimeout is the maximum time a password command can run.
+var passwordCommandTimeout = 30 * time.Second
+
+// ReadPasswordFile reads and returns the password from the given filename.
+// Only a single trailing new line, "\n" or "\r\n", is removed, any other
+// whitespace is considered part of the password. It returns an error if the
+// password is empty.
+func ReadPasswordFile(filename string) ([]byte, error) {
+	password, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errs.FileError(err, filename)
+	}
+	password = bytes.TrimSuffix(password, []byte("\n"))
+	password = bytes.TrimSuffix(password, []byte("\r"))
+	if len(password) == 0 {
+		return nil, errors.Errorf("error reading %s: password file is empty", filename)
+	}
+	return password, nil
+}
+
+// ReadPasswordFromCommand runs the given command using the system shell and
+// returns its standard output as the password. The output will be trimmed at
+// the right. If the command fails, the error includes its standard error.
+func ReadPasswordFromCommand(command string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, errors.Errorf("error running password command: timeout after %s", passwordCommandTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.Errorf("error running password command: %v: %s", err, msg)
+		}
+		return nil, errors.Wrap(err, "error running password command")
+	}
+	return bytes.TrimRightFunc(stdout.Bytes(), unicode.IsSpace), nil
+}
+
 // ReadStringPasswordFromFile reads and returns the password from the given filename.
 // The contents of the file will be trimmed at the right.
 func ReadStringPasswordFromFile(filename string) (string, error) {
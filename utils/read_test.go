@@ -6,7 +6,9 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -166,6 +168,60 @@ func TestStringReadPasswordFromFile(t *testing.T) {
 	require.Equal(t, "my-password-on-file", s, "expected %s to equal %s", s, content)
 }
 
+func TestReadPasswordFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{"no new line", "my-password", "my-password", false},
+		{"new line", "my-password\n", "my-password", false},
+		{"windows new line", "my-password\r\n", "my-password", false},
+		{"only one new line", "my-password\n\n", "my-password\n", false},
+		{"spaces", " my password \n", " my password ", false},
+		{"empty", "", "", true},
+		{"only new line", "\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, cleanup := newFile(t, []byte(tt.content))
+			defer cleanup()
+
+			b, err := ReadPasswordFile(f.Name())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(b))
+		})
+	}
+
+	_, err := ReadPasswordFile("testdata/missing-password-file")
+	require.Error(t, err)
+}
+
+func TestReadPasswordFromCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a POSIX shell")
+	}
+
+	b, err := ReadPasswordFromCommand("echo my-password-on-command")
+	require.NoError(t, err)
+	require.Equal(t, []byte("my-password-on-command"), b)
+
+	_, err = ReadPasswordFromCommand("echo no password >&2; exit 1")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no password")
+
+	defer func(d time.Duration) { passwordCommandTimeout = d }(passwordCommandTimeout)
+	passwordCommandTimeout = 10 * time.Millisecond
+	_, err = ReadPasswordFromCommand("sleep 1")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timeout")
+}
+
 func TestReadInput(t *testing.T) {
 
 	type args struct {
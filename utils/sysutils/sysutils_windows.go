@@ -1,6 +1,7 @@
 package sysutils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"syscall"
@@ -28,12 +29,26 @@ func flock(fd int, how int) error {
 	return syscall.EWINDOWS
 }
 
+// lockOffset is the offset of the byte locked by fileLock. Windows locks are
+// mandatory, so the lock is taken far past the end of the file to block other
+// lockers without blocking reads and writes on other handles.
+var lockOffset = windows.Overlapped{Offset: ^uint32(0), OffsetHigh: ^uint32(0) >> 1}
+
+// fileLock acquires a non-blocking exclusive lock on the file. A lock held by
+// another process is reported as syscall.EWOULDBLOCK to match the behavior of
+// flock(2) with LOCK_NB.
 func fileLock(fd int) error {
-	return nil
+	ol := lockOffset
+	err := windows.LockFileEx(windows.Handle(fd), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return syscall.EWOULDBLOCK
+	}
+	return err
 }
 
 func fileUnlock(fd int) error {
-	return nil
+	ol := lockOffset
+	return windows.UnlockFileEx(windows.Handle(fd), 0, 1, 0, &ol)
 }
 
 func kill(pid int, signum syscall.Signal) error {